@@ -0,0 +1,92 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tbruyelle/keyring-compat/codec"
+
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// adr036SignDoc is the amino JSON sign doc of an ADR-036 MsgSignData, fields
+// are declared in alphabetical order so the JSON is sorted.
+type adr036SignDoc struct {
+	AccountNumber string      `json:"account_number"`
+	ChainID       string      `json:"chain_id"`
+	Fee           adr036Fee   `json:"fee"`
+	Memo          string      `json:"memo"`
+	Msgs          []adr036Msg `json:"msgs"`
+	Sequence      string      `json:"sequence"`
+}
+
+type adr036Fee struct {
+	Amount []struct{} `json:"amount"`
+	Gas    string     `json:"gas"`
+}
+
+type adr036Msg struct {
+	Type  string            `json:"type"`
+	Value adr036MsgSignData `json:"value"`
+}
+
+type adr036MsgSignData struct {
+	Data   []byte `json:"data"`
+	Signer string `json:"signer"`
+}
+
+// adr036SignBytes returns the ADR-036 sign bytes of data signed by the bech32
+// address signer.
+func adr036SignBytes(signer string, data []byte) ([]byte, error) {
+	return json.Marshal(adr036SignDoc{
+		AccountNumber: "0",
+		Fee:           adr036Fee{Amount: []struct{}{}, Gas: "0"},
+		Msgs: []adr036Msg{{
+			Type:  "sign/MsgSignData",
+			Value: adr036MsgSignData{Data: data, Signer: signer},
+		}},
+		Sequence: "0",
+	})
+}
+
+// SignArbitrary signs data following ADR-036, like Keplr's signArbitrary
+// does: data is wrapped in a MsgSignData signed by the address of the key with
+// the bech32 prefix signerPrefix, and the amino JSON sign doc of this message
+// is signed with an empty chain-id, account number and sequence. Ledger keys
+// are supported, since the sign doc is in the amino JSON format.
+//
+// The signature can be checked with VerifyArbitrary.
+func (k Key) SignArbitrary(signerPrefix string, data []byte) ([]byte, error) {
+	signer, err := k.Bech32Address(signerPrefix)
+	if err != nil {
+		return nil, err
+	}
+	signBytes, err := adr036SignBytes(signer, data)
+	if err != nil {
+		return nil, err
+	}
+	return k.Sign(signBytes)
+}
+
+// VerifyArbitrary returns nil if sig is a valid ADR-036 signature of data,
+// made by pubKey which address is the bech32 address signer.
+func VerifyArbitrary(signer string, pubKey cryptotypes.PubKey, data, sig []byte) error {
+	_, addr, err := bech32.DecodeAndConvert(signer)
+	if err != nil {
+		return fmt.Errorf("invalid signer %q: %w", signer, err)
+	}
+	pubKeyAddr, err := codec.Address(pubKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(addr, pubKeyAddr) {
+		return fmt.Errorf("%w: public key doesn't match signer %s", ErrInvalidSignature, signer)
+	}
+	signBytes, err := adr036SignBytes(signer, data)
+	if err != nil {
+		return err
+	}
+	return verifySignature(pubKey, signBytes, sig)
+}
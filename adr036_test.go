@@ -0,0 +1,52 @@
+package keyring_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+)
+
+func TestSignArbitrary(t *testing.T) {
+	kr, _ := newLedgerKeyring(t)
+	privKey := secp256k1.GenPrivKeyFromSecret([]byte("secret"))
+	record, err := cosmoskeyring.NewLocalRecord("local", privKey, privKey.PubKey())
+	require.NoError(t, err)
+	info, err := keyring.LegacyInfoFromRecord(record)
+	require.NoError(t, err)
+	require.NoError(t, kr.AddAmino("local", info))
+	local, err := kr.Get("local")
+	require.NoError(t, err)
+	ledger, err := kr.AddLedger("ledger", hd.NewFundraiserParams(0, 118, 0), keyring.ProtoEncoding)
+	require.NoError(t, err)
+	data := []byte("challenge")
+
+	for _, key := range []keyring.Key{local, ledger} {
+		t.Run(key.Name(), func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			signer := key.MustBech32Address("osmo")
+			pubKey, err := key.PubKey()
+			require.NoError(err)
+
+			sig, err := key.SignArbitrary("osmo", data)
+
+			require.NoError(err)
+			// Sign doc as built by Keplr
+			signDoc := `{"account_number":"0","chain_id":"","fee":{"amount":[],"gas":"0"},"memo":"","msgs":[{"type":"sign/MsgSignData","value":{"data":"Y2hhbGxlbmdl","signer":"` + signer + `"}}],"sequence":"0"}`
+			assert.NoError(key.Verify([]byte(signDoc), sig))
+			assert.NoError(keyring.VerifyArbitrary(signer, pubKey, data, sig))
+			err = keyring.VerifyArbitrary(signer, pubKey, []byte("other"), sig)
+			assert.ErrorIs(err, keyring.ErrInvalidSignature)
+			err = keyring.VerifyArbitrary(key.MustBech32Address("cosmos"), pubKey, data, sig)
+			assert.ErrorIs(err, keyring.ErrInvalidSignature)
+			err = keyring.VerifyArbitrary(signer, secp256k1.GenPrivKey().PubKey(), data, sig)
+			assert.EqualError(err, "invalid signature: public key doesn't match signer "+signer)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package keyring
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/bcrypt"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/multisig"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/crypto/xsalsa20symmetric"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+// Armor parameters of the private keys, the same as crypto.EncryptArmorPrivKey
+// and crypto.UnarmorDecryptPrivKey of the cosmos-sdk. These functions are not
+// used because they encode the private key with the global amino codec, which
+// doesn't know the key types registered in the keyring codec.
+const (
+	blockTypePrivKey = "TENDERMINT PRIVATE KEY"
+	kdfArgon2        = "argon2"
+	kdfBcrypt        = "bcrypt"
+	argon2Time       = 1
+	argon2Memory     = 64 * 1024
+	argon2Threads    = 4
+)
+
+// ExportPrivKeyArmor returns the private key of the local key name, encrypted
+// with passphrase and ASCII armored. The format is the same as the one of the
+// cosmos-sdk `keys export` command, whatever the encoding of the key.
+func (k Keyring) ExportPrivKeyArmor(name, passphrase string) (string, error) {
+	key, err := k.Get(name)
+	if err != nil {
+		return "", err
+	}
+	return key.exportPrivKeyArmor(passphrase)
+}
+
+func (k Key) exportPrivKeyArmor(passphrase string) (string, error) {
+	privKey, err := k.getPrivKey()
+	if err != nil {
+		return "", err
+	}
+	bz, err := k.codecs().Amino.Marshal(privKey)
+	if err != nil {
+		return "", err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	aead, err := chacha20poly1305.New(argon2Key(passphrase, salt))
+	if err != nil {
+		return "", err
+	}
+	// Like the cosmos-sdk, the nonce is fixed because the key is derived from
+	// a random salt at each encryption.
+	nonce := make([]byte, aead.NonceSize())
+	header := map[string]string{
+		"kdf":  kdfArgon2,
+		"salt": fmt.Sprintf("%X", salt),
+		"type": privKey.Type(),
+	}
+	return crypto.EncodeArmor(blockTypePrivKey, header, aead.Seal(nil, nonce, bz, nil)), nil
+}
+
+// ImportPrivKeyArmor decrypts armor with passphrase and stores the private
+// key as a local key named name with the given encoding. armor must have the
+// format of the cosmos-sdk `keys export` command, or of ExportPrivKeyArmor.
+func (k Keyring) ImportPrivKeyArmor(name, armor, passphrase string, encoding Encoding) (Key, error) {
+	bz, err := decryptArmorPrivKey(armor, passphrase)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	var privKey cryptotypes.PrivKey
+	if err := k.cdc.Amino.Unmarshal(bz, &privKey); err != nil {
+		return Key{}, fmt.Errorf("cannot decode privkey: %w", err)
+	}
+	return k.addLocal(name, privKey, encoding)
+}
+
+// decryptArmorPrivKey returns the amino encoded private key of armor. Both
+// argon2 (cosmos-sdk >=v0.47) and bcrypt key derivations are supported.
+func decryptArmorPrivKey(armor, passphrase string) ([]byte, error) {
+	blockType, header, encBytes, err := crypto.DecodeArmor(armor)
+	if err != nil {
+		return nil, err
+	}
+	if blockType != blockTypePrivKey {
+		return nil, fmt.Errorf("unrecognized armor type: %v", blockType)
+	}
+	if header["salt"] == "" {
+		return nil, errors.New("missing salt bytes")
+	}
+	salt, err := hex.DecodeString(header["salt"])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding salt: %w", err)
+	}
+	switch header["kdf"] {
+	case kdfArgon2:
+		aead, err := chacha20poly1305.New(argon2Key(passphrase, salt))
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		bz, err := aead.Open(nil, nonce, encBytes, nil)
+		if err != nil {
+			return nil, sdkerrors.ErrWrongPassword
+		}
+		return bz, nil
+
+	case kdfBcrypt:
+		key, err := bcrypt.GenerateFromPassword(salt, []byte(passphrase), crypto.BcryptSecurityParameter)
+		if err != nil {
+			return nil, fmt.Errorf("bcrypt: %w", err)
+		}
+		secret := sha256.Sum256(key)
+		bz, err := xsalsa20symmetric.DecryptSymmetric(encBytes, secret[:])
+		if errors.Is(err, xsalsa20symmetric.ErrCiphertextDecrypt) {
+			return nil, sdkerrors.ErrWrongPassword
+		}
+		return bz, err
+	}
+	return nil, fmt.Errorf("unrecognized KDF type: %v", header["kdf"])
+}
+
+func argon2Key(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, argon2Time, argon2Memory, argon2Threads, chacha20poly1305.KeySize)
+}
+
+// ExportPubKeyArmor returns the public key of k ASCII armored, whatever its
+// type. Like cosmos-sdk >=v0.46, the public key is proto-encoded.
+func (k Key) ExportPubKeyArmor() (string, error) {
+	pubKey, err := k.PubKey()
+	if err != nil {
+		return "", err
+	}
+	bz, err := k.codecs().Proto.MarshalInterface(pubKey)
+	if err != nil {
+		return "", err
+	}
+	return crypto.ArmorPubKeyBytes(bz, pubKey.Type()), nil
+}
+
+// ImportPubKeyArmor stores the public key from armor as an offline key named
+// name with the given encoding, or as a multi key if it is a multisig public
+// key. Both proto and amino encoded public keys are supported, so armor can
+// come from ExportPubKeyArmor or from cosmos-sdk <v0.46.
+func (k Keyring) ImportPubKeyArmor(name, armor string, encoding Encoding) (Key, error) {
+	bz, _, err := crypto.UnarmorPubKeyBytes(armor)
+	if err != nil {
+		return Key{}, err
+	}
+	var pubKey cryptotypes.PubKey
+	errProto := k.cdc.Proto.UnmarshalInterface(bz, &pubKey)
+	if errProto != nil {
+		if errAmino := k.cdc.Amino.Unmarshal(bz, &pubKey); errAmino != nil {
+			return Key{}, fmt.Errorf("cannot decode pubkey: decodeProto=%v decodeAmino=%v", errProto, errAmino)
+		}
+	}
+	name = strings.TrimSuffix(name, infoSuffix)
+	var record *cosmoskeyring.Record
+	if _, ok := pubKey.(*multisig.LegacyAminoPubKey); ok {
+		record, err = cosmoskeyring.NewMultiRecord(name, pubKey)
+	} else {
+		record, err = cosmoskeyring.NewOfflineRecord(name, pubKey)
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	return k.addRecord(name, record, encoding)
+}
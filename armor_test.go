@@ -0,0 +1,144 @@
+package keyring_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+	"github.com/tbruyelle/keyring-compat/codec"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/multisig"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+)
+
+func TestPrivKeyArmor(t *testing.T) {
+	hdPath := hd.CreateHDPath(118, 0, 0).String()
+	for _, from := range []keyring.Encoding{keyring.AminoEncoding, keyring.ProtoEncoding} {
+		for _, to := range []keyring.Encoding{keyring.AminoEncoding, keyring.ProtoEncoding} {
+			t.Run(string(from)+" to "+string(to), func(t *testing.T) {
+				require := require.New(t)
+				assert := assert.New(t)
+				kr := newKeyring(t)
+				key, err := kr.ImportMnemonic("key", testMnemonic, "", hdPath, hd.Secp256k1, from)
+				require.NoError(err)
+
+				armor, err := kr.ExportPrivKeyArmor("key", "passphrase")
+
+				require.NoError(err)
+				kr2 := newKeyring(t)
+				_, err = kr2.ImportPrivKeyArmor("key", armor, "wrong", to)
+				assert.ErrorContains(err, "failed to decrypt private key")
+				key2, err := kr2.ImportPrivKeyArmor("key", armor, "passphrase", to)
+				require.NoError(err)
+				assert.Equal(to, key2.Encoding())
+				require.NoError(keyring.CompareKeys(key, key2))
+			})
+		}
+	}
+}
+
+func TestPrivKeyArmorCosmosSDKCompat(t *testing.T) {
+	require := require.New(t)
+	hdPath := hd.CreateHDPath(118, 0, 0).String()
+	sdkKr := cosmoskeyring.NewInMemory(codec.Proto)
+	_, err := sdkKr.NewAccount("sdk", testMnemonic, "", hdPath, hd.Secp256k1)
+	require.NoError(err)
+	kr := newKeyring(t)
+	key, err := kr.ImportMnemonic("key", testMnemonic, "", hdPath, hd.Secp256k1, keyring.AminoEncoding)
+	require.NoError(err)
+
+	// cosmos-sdk -> keyring-compat
+	sdkArmor, err := sdkKr.ExportPrivKeyArmor("sdk", "passphrase")
+	require.NoError(err)
+	kr2 := newKeyring(t)
+	key2, err := kr2.ImportPrivKeyArmor("key", sdkArmor, "passphrase", keyring.AminoEncoding)
+	require.NoError(err)
+	require.NoError(keyring.CompareKeys(key, key2))
+
+	// keyring-compat -> cosmos-sdk
+	armor, err := kr.ExportPrivKeyArmor("key", "passphrase")
+	require.NoError(err)
+	sdkKr2 := cosmoskeyring.NewInMemory(codec.Proto)
+	require.NoError(sdkKr2.ImportPrivKey("key", armor, "passphrase"))
+	record, err := sdkKr2.Key("key")
+	require.NoError(err)
+	addr, err := record.GetAddress()
+	require.NoError(err)
+	assert.Equal(t, key.MustBech32Address("cosmos"), addr.String())
+}
+
+func TestPubKeyArmor(t *testing.T) {
+	var (
+		kr, _   = newLedgerKeyring(t)
+		hdPath  = hd.CreateHDPath(118, 0, 0).String()
+		pk1     = secp256k1.GenPrivKeyFromSecret([]byte("secret1")).PubKey()
+		pk2     = secp256k1.GenPrivKeyFromSecret([]byte("secret2")).PubKey()
+		multiPK = multisig.NewLegacyAminoPubKey(1, []cryptotypes.PubKey{pk1, pk2})
+	)
+	_, err := kr.ImportMnemonic("local", testMnemonic, "", hdPath, hd.Secp256k1, keyring.AminoEncoding)
+	require.NoError(t, err)
+	_, err = kr.AddLedger("ledger", hd.NewFundraiserParams(1, 118, 0), keyring.ProtoEncoding)
+	require.NoError(t, err)
+	offline, err := cosmoskeyring.NewOfflineRecord("offline", pk1)
+	require.NoError(t, err)
+	require.NoError(t, kr.AddProto("offline", offline))
+	multi, err := cosmoskeyring.NewMultiRecord("multi", multiPK)
+	require.NoError(t, err)
+	multiInfo, err := keyring.LegacyInfoFromRecord(multi)
+	require.NoError(t, err)
+	require.NoError(t, kr.AddAmino("multi", multiInfo))
+
+	tests := []struct {
+		name         string
+		expectedType cosmoskeyring.KeyType
+	}{
+		{name: "local", expectedType: cosmoskeyring.TypeOffline},
+		{name: "ledger", expectedType: cosmoskeyring.TypeOffline},
+		{name: "offline", expectedType: cosmoskeyring.TypeOffline},
+		{name: "multi", expectedType: cosmoskeyring.TypeMulti},
+	}
+	for _, tt := range tests {
+		for _, encoding := range []keyring.Encoding{keyring.AminoEncoding, keyring.ProtoEncoding} {
+			t.Run(tt.name+" to "+string(encoding), func(t *testing.T) {
+				require := require.New(t)
+				assert := assert.New(t)
+				key, err := kr.Get(tt.name)
+				require.NoError(err)
+
+				armor, err := key.ExportPubKeyArmor()
+
+				require.NoError(err)
+				kr2 := newKeyring(t)
+				key2, err := kr2.ImportPubKeyArmor(tt.name, armor, encoding)
+				require.NoError(err)
+				assert.Equal(encoding, key2.Encoding())
+				assert.Equal(tt.expectedType, key2.Type())
+				pubKey, err := key.PubKey()
+				require.NoError(err)
+				pubKey2, err := key2.PubKey()
+				require.NoError(err)
+				assert.True(pubKey.Equals(pubKey2))
+			})
+		}
+	}
+}
+
+func TestImportPubKeyArmorAmino(t *testing.T) {
+	require := require.New(t)
+	pubKey := secp256k1.GenPrivKeyFromSecret([]byte("secret")).PubKey()
+	// cosmos-sdk <v0.46 armor format
+	armor := crypto.ArmorPubKeyBytes(codec.Amino.MustMarshal(pubKey), pubKey.Type())
+	kr := newKeyring(t)
+
+	key, err := kr.ImportPubKeyArmor("offline", armor, keyring.AminoEncoding)
+
+	require.NoError(err)
+	pubKey2, err := key.PubKey()
+	require.NoError(err)
+	assert.True(t, pubKey.Equals(pubKey2))
+}
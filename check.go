@@ -0,0 +1,208 @@
+package keyring
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/99designs/keyring"
+)
+
+// ProblemKind is the kind of a problem found by Keyring.Check.
+type ProblemKind string
+
+const (
+	// ProblemUndecodableInfo is a .info item that can't be decoded.
+	ProblemUndecodableInfo ProblemKind = "undecodable info"
+	// ProblemDanglingAddress is a .address item that points to a key that
+	// doesn't exist, or to a key with a different address.
+	ProblemDanglingAddress ProblemKind = "dangling address"
+	// ProblemMissingAddress is a .info item without .address item.
+	ProblemMissingAddress ProblemKind = "missing address"
+	// ProblemDuplicateAddress is a .info item with the same address than an
+	// other .info item, the .address item can only point to one of them.
+	ProblemDuplicateAddress ProblemKind = "duplicate address"
+	// ProblemNameMismatch is a .info item which embedded name differs from
+	// the item key.
+	ProblemNameMismatch ProblemKind = "name mismatch"
+)
+
+// Problem is an inconsistency found by Keyring.Check.
+type Problem struct {
+	Kind ProblemKind
+	// Item is the key of the faulty item.
+	Item   string
+	Detail string
+	// Repaired is true if the problem has been fixed, see WithRepair.
+	Repaired bool
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s %q: %s", p.Kind, p.Item, p.Detail)
+}
+
+// CheckReport lists the problems found by Keyring.Check.
+type CheckReport []Problem
+
+// CheckOption configures Keyring.Check.
+type CheckOption func(*checkOptions)
+
+type checkOptions struct {
+	quarantine *Keyring
+}
+
+// WithRepair makes Keyring.Check fix the problems it finds:
+//   - undecodable .info items and dangling .address items are moved into
+//     quarantine.
+//   - missing .address items are created.
+//   - mismatched embedded names are replaced by the item key.
+//
+// Duplicate addresses are not repaired since there is no way to tell which
+// key should own the address.
+func WithRepair(quarantine Keyring) CheckOption {
+	return func(o *checkOptions) {
+		o.quarantine = &quarantine
+	}
+}
+
+// Check walks all the items of the keyring and reports the inconsistencies
+// between the .info and .address items, like the ones left by a Remove that
+// failed halfway.
+func (k Keyring) Check(opts ...CheckOption) (CheckReport, error) {
+	var o checkOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	items, err := k.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		report CheckReport
+		// keys holds the decodable keys indexed by item key
+		keys = make(map[string]Key)
+		// index holds the .address items data indexed by item key
+		index = make(map[string]string)
+	)
+	for _, item := range items {
+		switch {
+		case strings.HasSuffix(item.Key, infoSuffix):
+			key, err := k.Get(item.Key)
+			if err != nil {
+				report = append(report, Problem{
+					Kind:   ProblemUndecodableInfo,
+					Item:   item.Key,
+					Detail: err.Error(),
+				})
+				continue
+			}
+			keys[item.Key] = key
+		case strings.HasSuffix(item.Key, addressSuffix):
+			name := string(item.Data)
+			if !strings.HasSuffix(name, infoSuffix) {
+				name += infoSuffix
+			}
+			index[item.Key] = name
+		}
+	}
+	// Check .address items
+	for _, item := range items {
+		name, ok := index[item.Key]
+		if !ok {
+			continue
+		}
+		key, ok := keys[name]
+		if !ok {
+			report = append(report, Problem{
+				Kind:   ProblemDanglingAddress,
+				Item:   item.Key,
+				Detail: fmt.Sprintf("points to missing or undecodable key %q", name),
+			})
+			delete(index, item.Key)
+			continue
+		}
+		addr, err := key.Address()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", name, err)
+		}
+		if addrHexKey(addr) != item.Key {
+			report = append(report, Problem{
+				Kind:   ProblemDanglingAddress,
+				Item:   item.Key,
+				Detail: fmt.Sprintf("points to key %q which has address %s", name, addr),
+			})
+			delete(index, item.Key)
+		}
+	}
+	// Check .info items
+	for _, item := range items {
+		key, ok := keys[item.Key]
+		if !ok {
+			continue
+		}
+		addr, err := key.Address()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", item.Key, err)
+		}
+		switch name, ok := index[addrHexKey(addr)]; {
+		case !ok:
+			report = append(report, Problem{
+				Kind:   ProblemMissingAddress,
+				Item:   item.Key,
+				Detail: fmt.Sprintf("no address entry for %s", addr),
+			})
+		case name != item.Key:
+			report = append(report, Problem{
+				Kind:   ProblemDuplicateAddress,
+				Item:   item.Key,
+				Detail: fmt.Sprintf("address %s is already owned by key %q", addr, name),
+			})
+		}
+		if name := strings.TrimSuffix(item.Key, infoSuffix); key.embeddedName() != name {
+			report = append(report, Problem{
+				Kind:   ProblemNameMismatch,
+				Item:   item.Key,
+				Detail: fmt.Sprintf("embedded name is %q", key.embeddedName()),
+			})
+		}
+	}
+	if o.quarantine == nil {
+		return report, nil
+	}
+	for i := range report {
+		if err := k.repair(report[i], keys, *o.quarantine); err != nil {
+			return report, fmt.Errorf("repair %s: %w", report[i], err)
+		}
+		report[i].Repaired = report[i].Kind != ProblemDuplicateAddress
+	}
+	return report, nil
+}
+
+// repair fixes p, keys holds the decodable keys indexed by item key.
+func (k Keyring) repair(p Problem, keys map[string]Key, quarantine Keyring) error {
+	switch p.Kind {
+	case ProblemUndecodableInfo, ProblemDanglingAddress:
+		item, err := k.k.Get(p.Item)
+		if err != nil {
+			return err
+		}
+		if err := quarantine.k.Set(item); err != nil {
+			return err
+		}
+		return k.k.Remove(p.Item)
+
+	case ProblemMissingAddress:
+		addr, err := keys[p.Item].Address()
+		if err != nil {
+			return err
+		}
+		return k.k.Set(keyring.Item{Key: addrHexKey(addr), Data: []byte(p.Item)})
+
+	case ProblemNameMismatch:
+		bz, err := keys[p.Item].marshalWithName(strings.TrimSuffix(p.Item, infoSuffix))
+		if err != nil {
+			return err
+		}
+		return k.k.Set(keyring.Item{Key: p.Item, Data: bz})
+	}
+	return nil
+}
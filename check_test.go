@@ -0,0 +1,95 @@
+package keyring_test
+
+import (
+	"encoding/hex"
+	"sort"
+	"testing"
+
+	bkeyring "github.com/99designs/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestCheck(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		dir = t.TempDir()
+		pk1 = secp256k1.GenPrivKeyFromSecret([]byte("secret1")).PubKey()
+		pk2 = secp256k1.GenPrivKeyFromSecret([]byte("secret2")).PubKey()
+		pk3 = secp256k1.GenPrivKeyFromSecret([]byte("secret3")).PubKey()
+	)
+	kr, err := keyring.New(keyring.BackendType("file"), dir, testPassword)
+	require.NoError(err)
+	// raw gives access to the items of kr
+	raw, err := bkeyring.Open(bkeyring.Config{
+		AllowedBackends:  []bkeyring.BackendType{bkeyring.FileBackend},
+		FileDir:          dir,
+		FilePasswordFunc: testPassword,
+	})
+	require.NoError(err)
+	// valid key
+	record, err := cosmoskeyring.NewOfflineRecord("valid", pk1)
+	require.NoError(err)
+	require.NoError(kr.AddProto("valid", record))
+	// key without address index entry
+	record, err = cosmoskeyring.NewOfflineRecord("noaddr", pk2)
+	require.NoError(err)
+	require.NoError(kr.AddProto("noaddr", record))
+	addrNoAddr := hex.EncodeToString(pk2.Address()) + ".address"
+	require.NoError(raw.Remove(addrNoAddr))
+	// key with a wrong embedded name
+	record, err = cosmoskeyring.NewOfflineRecord("other", pk3)
+	require.NoError(err)
+	require.NoError(kr.AddProto("badname", record))
+	// undecodable key
+	require.NoError(raw.Set(bkeyring.Item{Key: "garbage.info", Data: []byte("garbage")}))
+	// dangling address
+	require.NoError(raw.Set(bkeyring.Item{Key: "deadbeef.address", Data: []byte("gone.info")}))
+	quarantine := newKeyring(t)
+
+	report, err := kr.Check()
+
+	require.NoError(err)
+	sort.Slice(report, func(i, j int) bool { return report[i].Item < report[j].Item })
+	expectedReport := keyring.CheckReport{
+		{Kind: keyring.ProblemNameMismatch, Item: "badname.info", Detail: `embedded name is "other"`},
+		{Kind: keyring.ProblemDanglingAddress, Item: "deadbeef.address", Detail: `points to missing or undecodable key "gone.info"`},
+		{Kind: keyring.ProblemUndecodableInfo, Item: "garbage.info", Detail: report[2].Detail},
+		{Kind: keyring.ProblemMissingAddress, Item: "noaddr.info", Detail: "no address entry for " + sdk.AccAddress(pk2.Address()).String()},
+	}
+	assert.Equal(expectedReport, report)
+
+	//-----------------------------------------
+	// Repair
+	report, err = kr.Check(keyring.WithRepair(quarantine))
+
+	require.NoError(err)
+	require.Len(report, 4)
+	for _, p := range report {
+		assert.True(p.Repaired, p.String())
+	}
+	report, err = kr.Check()
+	require.NoError(err)
+	assert.Empty(report)
+	// broken items are in quarantine
+	_, err = raw.Get("garbage.info")
+	assert.ErrorIs(err, bkeyring.ErrKeyNotFound)
+	_, err = raw.Get("deadbeef.address")
+	assert.ErrorIs(err, bkeyring.ErrKeyNotFound)
+	// address index has been rebuilt
+	key, err := kr.GetByAddress(sdk.AccAddress(pk2.Address()))
+	require.NoError(err)
+	assert.Equal("noaddr.info", key.Name())
+	// embedded name has been fixed
+	key, err = kr.Get("badname")
+	require.NoError(err)
+	record, err = key.AsRecord()
+	require.NoError(err)
+	assert.Equal("badname", record.Name)
+}
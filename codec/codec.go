@@ -1,21 +1,144 @@
 package codec
 
 import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"golang.org/x/crypto/sha3"
+
 	"github.com/cosmos/cosmos-sdk/codec"
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	cryptocodec "github.com/cosmos/cosmos-sdk/crypto/codec"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
 )
 
-var (
+// Codec is the set of codecs used to encode and decode keys, along with the
+// address derivation functions of the public key types.
+type Codec struct {
 	Proto *codec.ProtoCodec
 	Amino *codec.LegacyAmino
+
+	// addressFuncs holds the address derivation functions per public key type.
+	addressFuncs map[string]AddressFunc
+}
+
+var (
+	// Default is the codec set used when none is provided. The package level
+	// functions and variables refer to it.
+	Default = New()
+
+	Proto = Default.Proto
+	Amino = Default.Amino
 )
 
-func init() {
+// EthSecp256k1Type is the type of the ethermint secp256k1 public keys, used by
+// Evmos, Injective and other ethermint-based chains.
+const EthSecp256k1Type = "eth_secp256k1"
+
+// aminoRegisterFuncs are called by New on the amino codec of the new codec
+// sets, see RegisterAminoTypes.
+var aminoRegisterFuncs []func(*codec.LegacyAmino)
+
+// RegisterAminoTypes adds fn to the functions called by New to register types
+// in the amino codec of the new codec sets. The keyring package uses it to
+// register the LegacyInfo types of the amino encoded keys, so all codec sets
+// can decode them.
+func RegisterAminoTypes(fn func(*codec.LegacyAmino)) {
+	aminoRegisterFuncs = append(aminoRegisterFuncs, fn)
+}
+
+// New returns a new codec set, with the cosmos-sdk crypto types and the types
+// of RegisterAminoTypes registered.
+func New() *Codec {
 	registry := codectypes.NewInterfaceRegistry()
 	cryptocodec.RegisterInterfaces(registry)
-	Proto = codec.NewProtoCodec(registry)
+	amino := codec.NewLegacyAmino()
+	cryptocodec.RegisterCrypto(amino)
+	for _, fn := range aminoRegisterFuncs {
+		fn(amino)
+	}
+	return &Codec{
+		Proto: codec.NewProtoCodec(registry),
+		Amino: amino,
+		addressFuncs: map[string]AddressFunc{
+			EthSecp256k1Type: EthAddress,
+		},
+	}
+}
+
+// RegisterPubKey registers the public key type of pubKey, so keys using it
+// can be decoded. The type is registered in the Proto codec under its proto
+// type URL, and in the Amino codec under aminoName, for instance
+// "ethermint/PubKeyEthSecp256k1".
+//
+// Like the other Register methods, it must be called before the codecs are
+// used, typically right after New.
+func (c *Codec) RegisterPubKey(pubKey cryptotypes.PubKey, aminoName string) {
+	c.Proto.InterfaceRegistry().RegisterImplementations((*cryptotypes.PubKey)(nil), pubKey)
+	c.Amino.RegisterConcrete(pubKey, aminoName, nil)
+}
+
+// RegisterPrivKey registers the private key type of privKey, so local keys
+// using it can be decoded. The type is registered in the Proto codec under
+// its proto type URL, and in the Amino codec under aminoName, for instance
+// "ethermint/PrivKeyEthSecp256k1".
+func (c *Codec) RegisterPrivKey(privKey cryptotypes.PrivKey, aminoName string) {
+	c.Proto.InterfaceRegistry().RegisterImplementations((*cryptotypes.PrivKey)(nil), privKey)
+	c.Amino.RegisterConcrete(privKey, aminoName, nil)
+}
+
+// AddressFunc derives the address of a public key.
+type AddressFunc func(pubKey cryptotypes.PubKey) (cryptotypes.Address, error)
+
+// RegisterAddressFunc sets the function that derives the addresses of the
+// public keys which Type() is pubKeyType. By default, EthAddress is used for
+// EthSecp256k1Type, and the PubKey.Address method for the other types.
+func (c *Codec) RegisterAddressFunc(pubKeyType string, fn AddressFunc) {
+	c.addressFuncs[pubKeyType] = fn
+}
+
+// Address returns the address of pubKey, derived by the function registered
+// for its type.
+func (c *Codec) Address(pubKey cryptotypes.PubKey) (cryptotypes.Address, error) {
+	if fn, ok := c.addressFuncs[pubKey.Type()]; ok {
+		return fn(pubKey)
+	}
+	return pubKey.Address(), nil
+}
+
+// RegisterPubKey registers the public key type of pubKey in the Default codec
+// set, see Codec.RegisterPubKey.
+func RegisterPubKey(pubKey cryptotypes.PubKey, aminoName string) {
+	Default.RegisterPubKey(pubKey, aminoName)
+}
+
+// RegisterPrivKey registers the private key type of privKey in the Default
+// codec set, see Codec.RegisterPrivKey.
+func RegisterPrivKey(privKey cryptotypes.PrivKey, aminoName string) {
+	Default.RegisterPrivKey(privKey, aminoName)
+}
+
+// RegisterAddressFunc sets the address derivation function of pubKeyType in
+// the Default codec set, see Codec.RegisterAddressFunc.
+func RegisterAddressFunc(pubKeyType string, fn AddressFunc) {
+	Default.RegisterAddressFunc(pubKeyType, fn)
+}
+
+// Address returns the address of pubKey with the Default codec set, see
+// Codec.Address.
+func Address(pubKey cryptotypes.PubKey) (cryptotypes.Address, error) {
+	return Default.Address(pubKey)
+}
 
-	Amino = codec.NewLegacyAmino()
-	cryptocodec.RegisterCrypto(Amino)
+// EthAddress returns the ethereum address of a compressed secp256k1 public
+// key, which is the last 20 bytes of the keccak256 hash of the uncompressed
+// public key.
+func EthAddress(pubKey cryptotypes.PubKey) (cryptotypes.Address, error) {
+	pk, err := btcec.ParsePubKey(pubKey.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s public key: %w", pubKey.Type(), err)
+	}
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(pk.SerializeUncompressed()[1:])
+	return hash.Sum(nil)[12:], nil
 }
@@ -0,0 +1,217 @@
+package keyring_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+	"github.com/tbruyelle/keyring-compat/codec"
+
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+)
+
+// ethPubKey mimics the ethermint eth_secp256k1 public key, which has the same
+// wire format as the cosmos-sdk secp256k1 public key.
+type ethPubKey struct {
+	secp256k1.PubKey
+}
+
+func (*ethPubKey) XXX_MessageName() string { return "ethermint.crypto.v1.ethsecp256k1.PubKey" }
+
+func (*ethPubKey) Type() string { return codec.EthSecp256k1Type }
+
+func (k *ethPubKey) Equals(other cryptotypes.PubKey) bool {
+	return k.Type() == other.Type() && k.PubKey.Equals(&secp256k1.PubKey{Key: other.Bytes()})
+}
+
+// ethPrivKey mimics the ethermint eth_secp256k1 private key.
+type ethPrivKey struct {
+	secp256k1.PrivKey
+}
+
+func (*ethPrivKey) XXX_MessageName() string { return "ethermint.crypto.v1.ethsecp256k1.PrivKey" }
+
+func (*ethPrivKey) Type() string { return codec.EthSecp256k1Type }
+
+func (k *ethPrivKey) PubKey() cryptotypes.PubKey {
+	return &ethPubKey{PubKey: *k.PrivKey.PubKey().(*secp256k1.PubKey)}
+}
+
+// newEthKeyring returns a keyring which codec set knows the ethermint key
+// types.
+func newEthKeyring(t *testing.T) keyring.Keyring {
+	t.Helper()
+	cdc := codec.New()
+	cdc.RegisterPubKey(&ethPubKey{}, "ethermint/PubKeyEthSecp256k1")
+	cdc.RegisterPrivKey(&ethPrivKey{}, "ethermint/PrivKeyEthSecp256k1")
+	return newKeyring(t, keyring.WithCodec(cdc))
+}
+
+func TestRegisterPubKey(t *testing.T) {
+	// Private key 1, which ethereum address is well-known
+	privKey := &secp256k1.PrivKey{Key: make([]byte, 32)}
+	privKey.Key[31] = 1
+	pubKey := &ethPubKey{PubKey: *privKey.PubKey().(*secp256k1.PubKey)}
+	expectedAddr, err := hex.DecodeString("7e5f4552091a69125d5dfcb7b8c2659029395bdf")
+	require.NoError(t, err)
+	record, err := cosmoskeyring.NewOfflineRecord("eth", pubKey)
+	require.NoError(t, err)
+
+	for _, encoding := range []keyring.Encoding{keyring.ProtoEncoding, keyring.AminoEncoding} {
+		t.Run(string(encoding), func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			kr := newEthKeyring(t)
+			addRecord(t, kr, record, encoding)
+
+			key, err := kr.Get("eth")
+
+			require.NoError(err)
+			assert.Equal(encoding, key.Encoding())
+			pk, err := key.PubKey()
+			require.NoError(err)
+			assert.IsType(&ethPubKey{}, pk)
+			addr, err := key.Address()
+			require.NoError(err)
+			assert.Equal(expectedAddr, addr.Bytes())
+			assert.Equal("inj10e0525sfrf53yh2aljmm3sn9jq5njk7lwfmzjf", key.MustBech32Address("inj"))
+			byAddr, err := kr.GetByAddress(addr)
+			require.NoError(err)
+			assert.Equal(key.Name(), byAddr.Name())
+			// The default codec doesn't know the type
+			_, err = newKeyring(t).Get("eth")
+			assert.Error(err)
+		})
+	}
+}
+
+func TestRegisterPrivKey(t *testing.T) {
+	privKey := &ethPrivKey{PrivKey: *secp256k1.GenPrivKeyFromSecret([]byte("secret"))}
+	record, err := cosmoskeyring.NewLocalRecord("eth", privKey, privKey.PubKey())
+	require.NoError(t, err)
+
+	for _, encoding := range []keyring.Encoding{keyring.ProtoEncoding, keyring.AminoEncoding} {
+		t.Run(string(encoding), func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			kr := newEthKeyring(t)
+			addRecord(t, kr, record, encoding)
+
+			key, err := kr.Get("eth")
+
+			require.NoError(err)
+			assert.Equal(encoding, key.Encoding())
+			assert.Equal(cosmoskeyring.TypeLocal, key.Type())
+			pk, err := key.PubKey()
+			require.NoError(err)
+			assert.IsType(&ethPubKey{}, pk)
+			expectedAddr, err := codec.EthAddress(pk)
+			require.NoError(err)
+			addr, err := key.Address()
+			require.NoError(err)
+			assert.EqualValues(expectedAddr, addr)
+			// The private key is decoded to sign
+			signature, err := key.Sign([]byte("hello world"))
+			require.NoError(err)
+			assert.True(pk.VerifySignature([]byte("hello world"), signature))
+			// The private key is encoded with the keyring codec in the armor
+			armor, err := kr.ExportPrivKeyArmor("eth", "passphrase")
+			require.NoError(err)
+			key2, err := newEthKeyring(t).ImportPrivKeyArmor("eth", armor, "passphrase", encoding)
+			require.NoError(err)
+			require.NoError(keyring.CompareKeys(key, key2))
+			pk2, err := key2.PubKey()
+			require.NoError(err)
+			assert.IsType(&ethPubKey{}, pk2)
+		})
+	}
+}
+
+// chainAPubKey and chainBPubKey are public keys of two chains that use the same
+// amino name.
+type (
+	chainAPubKey struct{ secp256k1.PubKey }
+	chainBPubKey struct{ secp256k1.PubKey }
+)
+
+func (*chainAPubKey) XXX_MessageName() string { return "chaina.crypto.PubKey" }
+
+func (*chainBPubKey) XXX_MessageName() string { return "chainb.crypto.PubKey" }
+
+func TestWithCodec(t *testing.T) {
+	var (
+		protoPubKey = *secp256k1.GenPrivKey().PubKey().(*secp256k1.PubKey)
+		aminoPubKey = *secp256k1.GenPrivKey().PubKey().(*secp256k1.PubKey)
+	)
+	tests := []struct {
+		name        string
+		protoPubKey cryptotypes.PubKey
+		aminoPubKey cryptotypes.PubKey
+	}{
+		{
+			name:        "chain a",
+			protoPubKey: &chainAPubKey{PubKey: protoPubKey},
+			aminoPubKey: &chainAPubKey{PubKey: aminoPubKey},
+		},
+		{
+			name:        "chain b",
+			protoPubKey: &chainBPubKey{PubKey: protoPubKey},
+			aminoPubKey: &chainBPubKey{PubKey: aminoPubKey},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			// Both chains use the same amino name, which would panic with a
+			// shared codec.
+			cdc := codec.New()
+			cdc.RegisterPubKey(tt.protoPubKey, "chain/PubKey")
+			cdc.RegisterAddressFunc(tt.protoPubKey.Type(), codec.EthAddress)
+			kr, err := keyring.New(keyring.BackendMemory, "", nil, keyring.WithCodec(cdc))
+			require.NoError(err)
+			record, err := cosmoskeyring.NewOfflineRecord("proto", tt.protoPubKey)
+			require.NoError(err)
+			require.NoError(kr.AddProto("proto", record))
+			record, err = cosmoskeyring.NewOfflineRecord("amino", tt.aminoPubKey)
+			require.NoError(err)
+			require.NoError(kr.AddProto("amino", record))
+			key, err := kr.Get("amino")
+			require.NoError(err)
+			info, err := key.RecordToInfo()
+			require.NoError(err)
+			addr, err := key.Address()
+			require.NoError(err)
+			assert.Equal(addr, info.GetAddress())
+			require.NoError(kr.Remove("amino"))
+			require.NoError(kr.AddAmino("amino", info))
+
+			for _, name := range []string{"proto", "amino"} {
+				key, err := kr.Get(name)
+				require.NoError(err)
+				pk, err := key.PubKey()
+				require.NoError(err)
+				assert.IsType(tt.protoPubKey, pk)
+				// The address is derived by the keyring codec
+				expectedAddr, err := codec.EthAddress(pk)
+				require.NoError(err)
+				addr, err := key.Address()
+				require.NoError(err)
+				assert.EqualValues(expectedAddr, addr)
+			}
+			// The default codec doesn't know the chain types
+			snapshot, err := kr.Snapshot()
+			require.NoError(err)
+			kr, err = keyring.New(keyring.BackendMemory, "", nil, keyring.WithSnapshot(snapshot))
+			require.NoError(err)
+			_, err = kr.Get("proto")
+			assert.ErrorContains(err, "cannot decode key proto.info")
+			_, err = kr.Get("amino")
+			assert.ErrorContains(err, "cannot decode key amino.info")
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package keyring
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+)
+
+// KeyMismatchError is returned by CompareKeys when two keys are not
+// equivalent.
+type KeyMismatchError struct {
+	Name  string
+	Diffs []string
+}
+
+func (e *KeyMismatchError) Error() string {
+	return fmt.Sprintf("key %q mismatch: %s", e.Name, strings.Join(e.Diffs, ", "))
+}
+
+// CompareKeys ensures that a and b are equivalent, whatever their encoding:
+// they must have the same name, type, public key, address and BIP44 path.
+// For local keys, a and b must also produce the same signature over a random
+// challenge.
+// If a and b are not equivalent, a *KeyMismatchError is returned.
+func CompareKeys(a, b Key) error {
+	var diffs []string
+	addDiff := func(field string, va, vb any) {
+		diffs = append(diffs, fmt.Sprintf("%s %v != %v", field, va, vb))
+	}
+	if a.name != b.name {
+		addDiff("name", a.name, b.name)
+	}
+	if a.embeddedName() != b.embeddedName() {
+		addDiff("embedded name", a.embeddedName(), b.embeddedName())
+	}
+	if a.Type() != b.Type() {
+		addDiff("type", a.Type(), b.Type())
+	}
+	pka, err := a.PubKey()
+	if err != nil {
+		return fmt.Errorf("key %q: %w", a.name, err)
+	}
+	pkb, err := b.PubKey()
+	if err != nil {
+		return fmt.Errorf("key %q: %w", b.name, err)
+	}
+	if !pka.Equals(pkb) {
+		addDiff("pubkey", pka, pkb)
+	}
+	addra, err := a.Address()
+	if err != nil {
+		return fmt.Errorf("key %q: %w", a.name, err)
+	}
+	addrb, err := b.Address()
+	if err != nil {
+		return fmt.Errorf("key %q: %w", b.name, err)
+	}
+	if !bytes.Equal(addra, addrb) {
+		addDiff("address", addra, addrb)
+	}
+	if a.Type() == cosmoskeyring.TypeLedger && b.Type() == cosmoskeyring.TypeLedger {
+		patha, err := a.getBip44Path()
+		if err != nil {
+			return fmt.Errorf("key %q: getBip44Path: %w", a.name, err)
+		}
+		pathb, err := b.getBip44Path()
+		if err != nil {
+			return fmt.Errorf("key %q: getBip44Path: %w", b.name, err)
+		}
+		if patha.String() != pathb.String() {
+			addDiff("path", patha, pathb)
+		}
+	}
+	if a.Type() == cosmoskeyring.TypeLocal && b.Type() == cosmoskeyring.TypeLocal {
+		challenge := make([]byte, 32)
+		if _, err := rand.Read(challenge); err != nil {
+			return err
+		}
+		siga, err := a.Sign(challenge)
+		if err != nil {
+			return fmt.Errorf("key %q: Sign: %w", a.name, err)
+		}
+		sigb, err := b.Sign(challenge)
+		if err != nil {
+			return fmt.Errorf("key %q: Sign: %w", b.name, err)
+		}
+		if !bytes.Equal(siga, sigb) {
+			addDiff("signature", fmt.Sprintf("%X", siga), fmt.Sprintf("%X", sigb))
+		}
+	}
+	if len(diffs) > 0 {
+		return &KeyMismatchError{Name: a.name, Diffs: diffs}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package keyring_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+)
+
+func TestCompareKeys(t *testing.T) {
+	//-----------------------------------------
+	// Setup
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		privKey      = secp256k1.GenPrivKeyFromSecret([]byte("secret"))
+		otherPrivKey = secp256k1.GenPrivKeyFromSecret([]byte("other"))
+		protoKr      = newKeyring(t)
+		aminoKr      = newKeyring(t)
+		otherKr      = newKeyring(t)
+	)
+	// protoKr and aminoKr hold the same local key, and ledger keys with
+	// different paths.
+	record, err := cosmoskeyring.NewLocalRecord("local", privKey, privKey.PubKey())
+	require.NoError(err)
+	require.NoError(protoKr.AddProto("local", record))
+	info, err := keyring.LegacyInfoFromRecord(record)
+	require.NoError(err)
+	require.NoError(aminoKr.AddAmino("local", info))
+	record, err = cosmoskeyring.NewLedgerRecord("ledger", privKey.PubKey(), hd.NewFundraiserParams(0, 118, 0))
+	require.NoError(err)
+	require.NoError(protoKr.AddProto("ledger", record))
+	record, err = cosmoskeyring.NewLedgerRecord("ledger", privKey.PubKey(), hd.NewFundraiserParams(0, 118, 1))
+	require.NoError(err)
+	info, err = keyring.LegacyInfoFromRecord(record)
+	require.NoError(err)
+	require.NoError(aminoKr.AddAmino("ledger", info))
+	// otherKr holds another local key with the same name
+	record, err = cosmoskeyring.NewLocalRecord("local", otherPrivKey, otherPrivKey.PubKey())
+	require.NoError(err)
+	require.NoError(otherKr.AddProto("local", record))
+	local, err := protoKr.Get("local")
+	require.NoError(err)
+	localAmino, err := aminoKr.Get("local")
+	require.NoError(err)
+	other, err := otherKr.Get("local")
+	require.NoError(err)
+	ledger, err := protoKr.Get("ledger")
+	require.NoError(err)
+	ledgerAmino, err := aminoKr.Get("ledger")
+	require.NoError(err)
+
+	//-----------------------------------------
+	// Same local key with different encodings
+	assert.NoError(keyring.CompareKeys(local, localAmino))
+
+	//-----------------------------------------
+	// Different local keys
+	err = keyring.CompareKeys(local, other)
+	var mismatchErr *keyring.KeyMismatchError
+	require.ErrorAs(err, &mismatchErr)
+	assert.Equal("local.info", mismatchErr.Name)
+	require.Len(mismatchErr.Diffs, 3)
+	assert.Equal("pubkey "+privKey.PubKey().String()+" != "+otherPrivKey.PubKey().String(), mismatchErr.Diffs[0])
+	assert.Equal("address "+privKey.PubKey().Address().String()+" != "+otherPrivKey.PubKey().Address().String(), mismatchErr.Diffs[1])
+	assert.Contains(mismatchErr.Diffs[2], "signature")
+
+	//-----------------------------------------
+	// Ledger keys with different paths
+	err = keyring.CompareKeys(ledger, ledgerAmino)
+	require.ErrorAs(err, &mismatchErr)
+	assert.Equal([]string{"path m/44'/118'/0'/0/0 != m/44'/118'/0'/0/1"}, mismatchErr.Diffs)
+
+	//-----------------------------------------
+	// Different names and types
+	err = keyring.CompareKeys(local, ledger)
+	require.ErrorAs(err, &mismatchErr)
+	assert.Equal([]string{
+		"name local.info != ledger.info",
+		"embedded name local != ledger",
+		"type local != ledger",
+	}, mismatchErr.Diffs)
+}
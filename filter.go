@@ -0,0 +1,58 @@
+package keyring
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Filter selects keys of a keyring. A key is selected if it matches all the
+// non-empty criteria of the filter, an empty filter selects all keys.
+type Filter struct {
+	// Names selects keys whose name is one of Names.
+	Names []string
+	// Pattern selects keys whose name matches the glob pattern, see path.Match
+	// for the syntax.
+	Pattern string
+	// Types selects keys whose type is one of Types.
+	Types []cosmoskeyring.KeyType
+	// Addresses selects keys whose address is one of Addresses.
+	Addresses []sdk.AccAddress
+}
+
+// MatchName returns true if name matches the name criteria of f. The key name
+// may have the .info suffix or not.
+func (f Filter) MatchName(name string) (bool, error) {
+	name = strings.TrimSuffix(name, infoSuffix)
+	if len(f.Names) > 0 && !slices.Contains(f.Names, name) {
+		return false, nil
+	}
+	if f.Pattern != "" {
+		return path.Match(f.Pattern, name)
+	}
+	return true, nil
+}
+
+// Match returns true if key matches all the criteria of f.
+func (f Filter) Match(key Key) (bool, error) {
+	ok, err := f.MatchName(key.name)
+	if err != nil || !ok {
+		return false, err
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, key.Type()) {
+		return false, nil
+	}
+	if len(f.Addresses) > 0 {
+		addr, err := key.Address()
+		if err != nil {
+			return false, err
+		}
+		return slices.ContainsFunc(f.Addresses, func(a sdk.AccAddress) bool {
+			return a.Equals(addr)
+		}), nil
+	}
+	return true, nil
+}
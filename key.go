@@ -1,19 +1,30 @@
 package keyring
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/tbruyelle/keyring-compat/codec"
 
-	ledger "github.com/cosmos/ledger-cosmos-go"
-
 	cosmoscodec "github.com/cosmos/cosmos-sdk/codec"
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	"github.com/cosmos/cosmos-sdk/crypto/hd"
 	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/multisig"
 	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+// Encoding is the encoding used to store a key in the keyring.
+type Encoding string
+
+const (
+	// AminoEncoding is the encoding of keys created with cosmos-sdk <v0.46.
+	AminoEncoding Encoding = "amino"
+	// ProtoEncoding is the encoding of keys created with cosmos-sdk >=v0.46.
+	ProtoEncoding Encoding = "proto"
 )
 
 type Key struct {
@@ -22,12 +33,61 @@ type Key struct {
 	record *cosmoskeyring.Record
 	// info is not nil if the key is amino-encoded
 	info cosmoskeyring.LegacyInfo
+	// ledger is the device used to sign with ledger keys
+	ledger LedgerDevice
+	// cdc is the codec set of the keyring of the key
+	cdc *codec.Codec
+}
+
+// codecs returns the codec set of k, or the default one if k doesn't come
+// from a keyring.
+func (k Key) codecs() *codec.Codec {
+	if k.cdc == nil {
+		return codec.Default
+	}
+	return k.cdc
 }
 
 func (k Key) Name() string {
 	return k.name
 }
 
+// embeddedName returns the name stored inside the Record or the LegacyInfo.
+func (k Key) embeddedName() string {
+	if k.IsAminoEncoded() {
+		return k.info.GetName()
+	}
+	return k.record.Name
+}
+
+// marshalWithName returns k encoded with its original encoding, with name as
+// the embedded name.
+func (k Key) marshalWithName(name string) ([]byte, error) {
+	if k.IsAminoEncoded() {
+		var info cosmoskeyring.LegacyInfo
+		switch i := k.info.(type) {
+		case legacyLocalInfo:
+			i.Name = name
+			info = i
+		case legacyLedgerInfo:
+			i.Name = name
+			info = i
+		case legacyOfflineInfo:
+			i.Name = name
+			info = i
+		case legacyMultiInfo:
+			i.Name = name
+			info = i
+		default:
+			return nil, fmt.Errorf("unexpected info type %T", k.info)
+		}
+		return k.codecs().Amino.MarshalLengthPrefixed(info)
+	}
+	record := *k.record
+	record.Name = name
+	return k.codecs().Proto.Marshal(&record)
+}
+
 func (k Key) MustBech32Address(prefix string) string {
 	addr, err := k.Bech32Address(prefix)
 	if err != nil {
@@ -37,11 +97,11 @@ func (k Key) MustBech32Address(prefix string) string {
 }
 
 func (k Key) Bech32Address(prefix string) (string, error) {
-	pk, err := k.PubKey()
+	addr, err := k.Address()
 	if err != nil {
 		return "", err
 	}
-	return bech32.ConvertAndEncode(prefix, pk.Address())
+	return bech32.ConvertAndEncode(prefix, addr)
 }
 
 func (k Key) PubKey() (cryptotypes.PubKey, error) {
@@ -55,12 +115,23 @@ func (k Key) PubKey() (cryptotypes.PubKey, error) {
 	return pk, nil
 }
 
+// Address returns the address of the key, derived from its public key with
+// the function registered in the codec package for the public key type.
 func (k Key) Address() (sdk.AccAddress, error) {
 	pk, err := k.PubKey()
 	if err != nil {
 		return nil, err
 	}
-	return pk.Address().Bytes(), nil
+	return pubKeyAddress(k.codecs(), pk)
+}
+
+// pubKeyAddress returns the address of pk, see codec.Codec.Address.
+func pubKeyAddress(cdc *codec.Codec, pk cryptotypes.PubKey) (sdk.AccAddress, error) {
+	addr, err := cdc.Address(pk)
+	if err != nil {
+		return nil, err
+	}
+	return addr.Bytes(), nil
 }
 
 // ProtoJSONPubKey returns k's public key in the proto JSON format.
@@ -84,8 +155,39 @@ func (k Key) IsAminoEncoded() bool {
 	return k.info != nil
 }
 
+// Encoding returns the encoding used to store k.
+func (k Key) Encoding() Encoding {
+	if k.IsAminoEncoded() {
+		return AminoEncoding
+	}
+	return ProtoEncoding
+}
+
+// RecordToInfo converts the Record of a proto-encoded key into a LegacyInfo.
+// It fails if k is amino-encoded.
 func (k Key) RecordToInfo() (cosmoskeyring.LegacyInfo, error) {
-	return LegacyInfoFromRecord(k.record)
+	if k.IsAminoEncoded() {
+		return nil, fmt.Errorf("key %q is already amino encoded", k.name)
+	}
+	return legacyInfoFromRecord(k.codecs(), k.record)
+}
+
+// AsRecord returns k as a Record, whatever its encoding. Amino-encoded keys
+// are converted on the fly, the keyring is left untouched.
+func (k Key) AsRecord() (*cosmoskeyring.Record, error) {
+	if k.IsAminoEncoded() {
+		return k.InfoToRecord()
+	}
+	return k.record, nil
+}
+
+// InfoToRecord converts the LegacyInfo of an amino-encoded key into a Record.
+// It fails if k is proto-encoded.
+func (k Key) InfoToRecord() (*cosmoskeyring.Record, error) {
+	if !k.IsAminoEncoded() {
+		return nil, fmt.Errorf("key %q is already proto encoded", k.name)
+	}
+	return recordFromLegacyInfo(k.codecs(), k.info)
 }
 
 func (k Key) Type() cosmoskeyring.KeyType {
@@ -95,7 +197,30 @@ func (k Key) Type() cosmoskeyring.KeyType {
 	return k.record.GetType()
 }
 
-func (k Key) Sign(bz []byte) ([]byte, error) {
+// SignOption configures Key.Sign.
+type SignOption func(*signOptions)
+
+type signOptions struct {
+	signMode signing.SignMode
+}
+
+// WithSignMode sets the sign mode used by ledger keys, which can be either
+// SIGN_MODE_LEGACY_AMINO_JSON (default) or SIGN_MODE_TEXTUAL. bz must be
+// encoded accordingly. This option has no effect on local keys.
+//
+// With SignTx, it sets the sign mode used to build the sign bytes, see SignTx.
+func WithSignMode(signMode signing.SignMode) SignOption {
+	return func(o *signOptions) {
+		o.signMode = signMode
+	}
+}
+
+func (k Key) Sign(bz []byte, opts ...SignOption) ([]byte, error) {
+	o := signOptions{signMode: signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	switch k.Type() {
 	case cosmoskeyring.TypeLocal:
 		privKey, err := k.getPrivKey()
@@ -109,15 +234,18 @@ func (k Key) Sign(bz []byte) ([]byte, error) {
 		return signature, nil
 
 	case cosmoskeyring.TypeLedger:
-		device, err := ledger.FindLedgerCosmosUserApp()
-		if err != nil {
-			return nil, err
-		}
-		return signWithLedger(device, k, bz)
+		return signWithLedger(k.ledgerDevice(), k, bz, o.signMode)
 	}
 	return nil, fmt.Errorf("unhandled key type %q", k.Type())
 }
 
+func (k Key) ledgerDevice() LedgerDevice {
+	if k.ledger == nil {
+		return hidLedger{}
+	}
+	return k.ledger
+}
+
 func (k Key) getBip44Path() (*hd.BIP44Params, error) {
 	if k.IsAminoEncoded() {
 		return k.info.GetPath()
@@ -131,17 +259,21 @@ func (k Key) getPrivKey() (cryptotypes.PrivKey, error) {
 	}
 	if k.IsAminoEncoded() {
 		// Get priv key from amino encoded key
-		var privKey cryptotypes.PrivKey
-		err := codec.Amino.Unmarshal([]byte(k.info.(legacyLocalInfo).GetPrivKeyArmor()), &privKey)
-		if err != nil {
-			return nil, err
-		}
-		return privKey, nil
+		return extractPrivKeyFromLocalInfo(k.codecs(), k.info.(legacyLocalInfo))
 	}
 	// Get priv key from proto encoded key
 	return extractPrivKeyFromLocal(k.record.GetLocal())
 }
 
+func extractPrivKeyFromLocalInfo(cdc *codec.Codec, info legacyLocalInfo) (cryptotypes.PrivKey, error) {
+	var privKey cryptotypes.PrivKey
+	err := cdc.Amino.Unmarshal([]byte(info.GetPrivKeyArmor()), &privKey)
+	if err != nil {
+		return nil, err
+	}
+	return privKey, nil
+}
+
 func extractPrivKeyFromLocal(rl *cosmoskeyring.Record_Local) (cryptotypes.PrivKey, error) {
 	if rl.PrivKey == nil {
 		return nil, cosmoskeyring.ErrPrivKeyNotAvailable
@@ -155,8 +287,20 @@ func extractPrivKeyFromLocal(rl *cosmoskeyring.Record_Local) (cryptotypes.PrivKe
 	return priv, nil
 }
 
-// LegacyInfoFromRecord turns a Record into a LegacyInfo.
+// LegacyInfoFromRecord turns a Record into a LegacyInfo, using the default
+// codec set. Use Key.RecordToInfo for keys of a keyring with its own codec set.
 func LegacyInfoFromRecord(record *cosmoskeyring.Record) (cosmoskeyring.LegacyInfo, error) {
+	return legacyInfoFromRecord(codec.Default, record)
+}
+
+func legacyInfoFromRecord(cdc *codec.Codec, record *cosmoskeyring.Record) (cosmoskeyring.LegacyInfo, error) {
+	if record == nil {
+		return nil, errors.New("nil record")
+	}
+	if record.GetItem() == nil {
+		// Record.GetType() panics when the item is unset, so report it upfront.
+		return nil, fmt.Errorf("record %q has no type", record.Name)
+	}
 	switch record.GetType() {
 	case cosmoskeyring.TypeLocal:
 		pk, err := record.GetPubKey()
@@ -167,7 +311,7 @@ func LegacyInfoFromRecord(record *cosmoskeyring.Record) (cosmoskeyring.LegacyInf
 		if err != nil {
 			return nil, err
 		}
-		privBz, err := codec.Amino.Marshal(privKey)
+		privBz, err := cdc.Amino.Marshal(privKey)
 		if err != nil {
 			return nil, err
 		}
@@ -176,6 +320,7 @@ func LegacyInfoFromRecord(record *cosmoskeyring.Record) (cosmoskeyring.LegacyInf
 			PubKey:       pk,
 			Algo:         hd.PubKeyType(pk.Type()),
 			PrivKeyArmor: string(privBz),
+			cdc:          cdc,
 		}, nil
 
 	case cosmoskeyring.TypeLedger:
@@ -188,13 +333,81 @@ func LegacyInfoFromRecord(record *cosmoskeyring.Record) (cosmoskeyring.LegacyInf
 			PubKey: pk,
 			Algo:   hd.PubKeyType(pk.Type()),
 			Path:   *record.GetLedger().Path,
+			cdc:    cdc,
+		}, nil
+
+	case cosmoskeyring.TypeOffline:
+		pk, err := record.GetPubKey()
+		if err != nil {
+			return nil, err
+		}
+		return legacyOfflineInfo{
+			Name:   record.Name,
+			PubKey: pk,
+			Algo:   hd.PubKeyType(pk.Type()),
+			cdc:    cdc,
 		}, nil
 
 	case cosmoskeyring.TypeMulti:
-		panic("record type TypeMulti unhandled")
+		pk, err := record.GetPubKey()
+		if err != nil {
+			return nil, err
+		}
+		multiPK, ok := pk.(*multisig.LegacyAminoPubKey)
+		if !ok {
+			return nil, fmt.Errorf("multi record supports only multisig.LegacyAminoPubKey, got %T", pk)
+		}
+		pubKeys := make([]multisigPubKeyInfo, len(multiPK.GetPubKeys()))
+		for i, pk := range multiPK.GetPubKeys() {
+			// LegacyAminoPubKey doesn't handle weights, like in cosmos-sdk each
+			// member is given a weight of 1.
+			pubKeys[i] = multisigPubKeyInfo{PubKey: pk, Weight: 1}
+		}
+		return legacyMultiInfo{
+			Name:      record.Name,
+			PubKey:    multiPK,
+			Threshold: uint(multiPK.Threshold),
+			PubKeys:   pubKeys,
+			cdc:       cdc,
+		}, nil
+	}
+	return nil, fmt.Errorf("record type %s unhandled", record.GetType())
+}
+
+// RecordFromLegacyInfo turns a LegacyInfo into a Record, using the default
+// codec set. Use Key.InfoToRecord for keys of a keyring with its own codec set.
+func RecordFromLegacyInfo(info cosmoskeyring.LegacyInfo) (*cosmoskeyring.Record, error) {
+	return recordFromLegacyInfo(codec.Default, info)
+}
+
+func recordFromLegacyInfo(cdc *codec.Codec, info cosmoskeyring.LegacyInfo) (*cosmoskeyring.Record, error) {
+	if info == nil {
+		return nil, errors.New("nil info")
+	}
+	switch info.GetType() {
+	case cosmoskeyring.TypeLocal:
+		localInfo, ok := info.(legacyLocalInfo)
+		if !ok {
+			return nil, fmt.Errorf("unexpected local info type %T", info)
+		}
+		privKey, err := extractPrivKeyFromLocalInfo(cdc, localInfo)
+		if err != nil {
+			return nil, err
+		}
+		return cosmoskeyring.NewLocalRecord(info.GetName(), privKey, info.GetPubKey())
+
+	case cosmoskeyring.TypeLedger:
+		path, err := info.GetPath()
+		if err != nil {
+			return nil, err
+		}
+		return cosmoskeyring.NewLedgerRecord(info.GetName(), info.GetPubKey(), path)
 
 	case cosmoskeyring.TypeOffline:
-		panic("record type TypeOffline unhandled")
+		return cosmoskeyring.NewOfflineRecord(info.GetName(), info.GetPubKey())
+
+	case cosmoskeyring.TypeMulti:
+		return cosmoskeyring.NewMultiRecord(info.GetName(), info.GetPubKey())
 	}
-	panic(fmt.Sprintf("record type %s unhandled", record.GetType()))
+	return nil, fmt.Errorf("info type %s unhandled", info.GetType())
 }
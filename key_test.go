@@ -0,0 +1,115 @@
+package keyring_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/multisig"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+)
+
+func TestLegacyInfoFromRecord(t *testing.T) {
+	//-----------------------------------------
+	// Setup
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		kr      = newKeyring(t)
+		pk1     = secp256k1.GenPrivKeyFromSecret([]byte("secret1")).PubKey()
+		pk2     = secp256k1.GenPrivKeyFromSecret([]byte("secret2")).PubKey()
+		multiPK = multisig.NewLegacyAminoPubKey(1, []cryptotypes.PubKey{pk1, pk2})
+	)
+
+	//-----------------------------------------
+	// Offline record
+	record, err := cosmoskeyring.NewOfflineRecord("offline", pk1)
+	require.NoError(err)
+	info, err := keyring.LegacyInfoFromRecord(record)
+	require.NoError(err)
+	assert.Equal(cosmoskeyring.TypeOffline, info.GetType())
+	assert.Equal("offline", info.GetName())
+	assert.True(pk1.Equals(info.GetPubKey()))
+	// Ensure the info can be stored and read back
+	require.NoError(kr.AddAmino("offline", info))
+	key, err := kr.Get("offline")
+	require.NoError(err)
+	assert.True(key.IsAminoEncoded())
+	assert.Equal(cosmoskeyring.TypeOffline, key.Type())
+	pk, err := key.PubKey()
+	require.NoError(err)
+	assert.True(pk1.Equals(pk))
+
+	//-----------------------------------------
+	// Multi record
+	record, err = cosmoskeyring.NewMultiRecord("multi", multiPK)
+	require.NoError(err)
+	info, err = keyring.LegacyInfoFromRecord(record)
+	require.NoError(err)
+	assert.Equal(cosmoskeyring.TypeMulti, info.GetType())
+	assert.Equal("multi", info.GetName())
+	assert.True(multiPK.Equals(info.GetPubKey()))
+	// Ensure the info can be stored and read back
+	require.NoError(kr.AddAmino("multi", info))
+	key, err = kr.Get("multi")
+	require.NoError(err)
+	assert.True(key.IsAminoEncoded())
+	assert.Equal(cosmoskeyring.TypeMulti, key.Type())
+	pk, err = key.PubKey()
+	require.NoError(err)
+	assert.True(multiPK.Equals(pk))
+
+	//-----------------------------------------
+	// Multi record with non multisig pubkey
+	record, err = cosmoskeyring.NewMultiRecord("multi", pk1)
+	require.NoError(err)
+	_, err = keyring.LegacyInfoFromRecord(record)
+	assert.EqualError(err, "multi record supports only multisig.LegacyAminoPubKey, got *secp256k1.PubKey")
+
+	//-----------------------------------------
+	// Empty or nil record
+	_, err = keyring.LegacyInfoFromRecord(&cosmoskeyring.Record{Name: "empty"})
+	assert.EqualError(err, `record "empty" has no type`)
+	_, err = keyring.LegacyInfoFromRecord(nil)
+	assert.EqualError(err, "nil record")
+}
+
+func TestRecordFromLegacyInfo(t *testing.T) {
+	//-----------------------------------------
+	// Setup
+	require := require.New(t)
+	var (
+		privKey = secp256k1.GenPrivKeyFromSecret([]byte("secret1"))
+		pk1     = privKey.PubKey()
+		pk2     = secp256k1.GenPrivKeyFromSecret([]byte("secret2")).PubKey()
+		multiPK = multisig.NewLegacyAminoPubKey(2, []cryptotypes.PubKey{pk1, pk2})
+	)
+	local, err := cosmoskeyring.NewLocalRecord("local", privKey, pk1)
+	require.NoError(err)
+	ledger, err := cosmoskeyring.NewLedgerRecord("ledger", pk1, hd.NewFundraiserParams(0, 118, 1))
+	require.NoError(err)
+	offline, err := cosmoskeyring.NewOfflineRecord("offline", pk1)
+	require.NoError(err)
+	multi, err := cosmoskeyring.NewMultiRecord("multi", multiPK)
+	require.NoError(err)
+
+	//-----------------------------------------
+	// Round trip with LegacyInfoFromRecord
+	for _, record := range []*cosmoskeyring.Record{local, ledger, offline, multi} {
+		info, err := keyring.LegacyInfoFromRecord(record)
+		require.NoError(err)
+		record2, err := keyring.RecordFromLegacyInfo(info)
+		require.NoError(err)
+		require.Equal(record.String(), record2.String(), record.Name)
+	}
+
+	//-----------------------------------------
+	// Nil info
+	_, err = keyring.RecordFromLegacyInfo(nil)
+	require.EqualError(err, "nil info")
+}
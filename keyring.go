@@ -2,14 +2,18 @@ package keyring
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/99designs/keyring"
 	"github.com/bgentry/speakeasy"
 	"github.com/tbruyelle/keyring-compat/codec"
 
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
 	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
 	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -21,28 +25,202 @@ const (
 )
 
 type Keyring struct {
-	dir string
-	k   keyring.Keyring
+	backend BackendType
+	dir     string
+	k       keyring.Keyring
+	ledger  LedgerDevice
+	algos   cosmoskeyring.SigningAlgoList
+	cdc     *codec.Codec
 }
 
 type BackendType = keyring.BackendType
 
-func New(backend BackendType, dir string, filePasswordFunc func(string) (string, error)) (Keyring, error) {
-	if filePasswordFunc == nil {
-		filePasswordFunc = func(_ string) (string, error) {
-			return speakeasy.FAsk(os.Stderr, fmt.Sprintf("Enter password for keyring %q: ", dir))
+// Option configures a Keyring.
+type Option func(*Keyring)
+
+// WithLedger sets the device used by the keys of the keyring to interact with
+// a ledger. By default, the first ledger device found with the Cosmos app
+// open is used.
+func WithLedger(device LedgerDevice) Option {
+	return func(k *Keyring) {
+		k.ledger = device
+	}
+}
+
+// WithSupportedAlgorithms sets the signature algorithms allowed to create
+// local keys from mnemonics. Default is secp256k1 only.
+func WithSupportedAlgorithms(algos ...cosmoskeyring.SignatureAlgo) Option {
+	return func(k *Keyring) {
+		k.algos = algos
+	}
+}
+
+// WithCodec sets the codec set used to encode and decode the keys of the
+// keyring, cdc must be created with codec.New. By default, codec.Default is
+// used, which is shared by all the keyrings of the process.
+func WithCodec(cdc *codec.Codec) Option {
+	return func(k *Keyring) {
+		k.cdc = cdc
+	}
+}
+
+// WithSnapshot seeds a memory keyring with items, typically returned by
+// Keyring.Snapshot of another keyring. It has no effect on other backends.
+func WithSnapshot(items []keyring.Item) Option {
+	return func(k *Keyring) {
+		if k.backend == BackendMemory {
+			k.k = newMemoryKeyring(items)
 		}
 	}
-	k, err := keyring.Open(keyring.Config{
+}
+
+// New opens the keyring of type backend located in dir. filePasswordFunc is
+// used by the file backend to prompt for the keyring password, if nil the
+// password is prompted on stdin. If dir is missing because of an interrupted
+// Keyring.ChangePassword, the password change is completed first.
+//
+// The BackendMemory backend keeps the items in memory only, with the same
+// layout as the file backend, dir is then only used as the parent directory of
+// the default migration targets. If dir is empty, migrations require the
+// WithTarget or WithInPlace option.
+func New(backend BackendType, dir string, filePasswordFunc func(string) (string, error), opts ...Option) (Keyring, error) {
+	return open(backend, keyring.Config{
 		// TODO: test with other backend
 		AllowedBackends:  []keyring.BackendType{backend},
 		FileDir:          dir,
-		FilePasswordFunc: filePasswordFunc,
-	})
-	if err != nil {
-		return Keyring{}, err
+		FilePasswordFunc: passwordPrompt(dir, filePasswordFunc),
+	}, opts)
+}
+
+// cosmos-sdk keyring backends, see Open.
+const (
+	BackendFile    = "file"
+	BackendOS      = "os"
+	BackendKWallet = "kwallet"
+	BackendPass    = "pass"
+	BackendTest    = "test"
+	BackendMemory  = "memory"
+)
+
+// Open opens the keyring of the application appName, the same way cosmos-sdk
+// does with the keyring-backend flag: backend is one of the Backend*
+// constants and rootDir is the application home directory. The sub-directory
+// layout, service names and the test backend password are resolved like
+// cosmos-sdk's keyring.New, so keyrings created by `<appd> keys` can be opened.
+//
+// filePasswordFunc is used by the file and os backends to prompt for the
+// keyring password, if nil the password is prompted on stdin. The memory
+// backend has no directory, so its migrations require the WithTarget or
+// WithInPlace option.
+func Open(appName, backend, rootDir string, filePasswordFunc func(string) (string, error), opts ...Option) (Keyring, error) {
+	var cfg keyring.Config
+	switch backend {
+	case BackendTest:
+		cfg = keyring.Config{
+			AllowedBackends: []keyring.BackendType{keyring.FileBackend},
+			ServiceName:     appName,
+			FileDir:         filepath.Join(rootDir, "keyring-test"),
+			FilePasswordFunc: func(_ string) (string, error) {
+				return "test", nil
+			},
+		}
+	case BackendFile:
+		dir := filepath.Join(rootDir, "keyring-file")
+		cfg = keyring.Config{
+			AllowedBackends:  []keyring.BackendType{keyring.FileBackend},
+			ServiceName:      appName,
+			FileDir:          dir,
+			FilePasswordFunc: passwordPrompt(dir, filePasswordFunc),
+		}
+	case BackendOS:
+		cfg = keyring.Config{
+			ServiceName:              appName,
+			FileDir:                  rootDir,
+			KeychainTrustApplication: true,
+			FilePasswordFunc:         passwordPrompt(rootDir, filePasswordFunc),
+		}
+	case BackendKWallet:
+		cfg = keyring.Config{
+			AllowedBackends: []keyring.BackendType{keyring.KWalletBackend},
+			ServiceName:     "kdewallet",
+			KWalletAppID:    appName,
+			KWalletFolder:   "",
+		}
+	case BackendPass:
+		cfg = keyring.Config{
+			AllowedBackends: []keyring.BackendType{keyring.PassBackend},
+			ServiceName:     appName,
+			PassPrefix:      fmt.Sprintf("keyring-%s", appName),
+		}
+	case BackendMemory:
+		cfg = keyring.Config{ServiceName: appName}
+	default:
+		return Keyring{}, fmt.Errorf("%w: %s", cosmoskeyring.ErrUnknownBacked, backend)
+	}
+	return open(BackendType(backend), cfg, opts)
+}
+
+// passwordPrompt returns filePasswordFunc, or if nil, a function that prompts
+// the password of the keyring located in dir.
+func passwordPrompt(dir string, filePasswordFunc func(string) (string, error)) func(string) (string, error) {
+	if filePasswordFunc != nil {
+		return filePasswordFunc
+	}
+	return func(_ string) (string, error) {
+		return speakeasy.FAsk(os.Stderr, fmt.Sprintf("Enter password for keyring %q: ", dir))
 	}
-	return Keyring{dir: dir, k: k}, nil
+}
+
+func open(backend BackendType, cfg keyring.Config, opts []Option) (Keyring, error) {
+	if backend == keyring.FileBackend {
+		if err := recoverChangePassword(cfg.FileDir); err != nil {
+			return Keyring{}, err
+		}
+	}
+	var k keyring.Keyring = newMemoryKeyring(nil)
+	if backend != BackendMemory {
+		var err error
+		k, err = keyring.Open(cfg)
+		if err != nil {
+			return Keyring{}, err
+		}
+	}
+	kr := Keyring{
+		backend: backend,
+		dir:     cfg.FileDir,
+		k:       k,
+		ledger:  hidLedger{},
+		algos:   cosmoskeyring.SigningAlgoList{hd.Secp256k1},
+		cdc:     codec.Default,
+	}
+	for _, opt := range opts {
+		opt(&kr)
+	}
+	return kr, nil
+}
+
+// memoryKeyring is the keyring.Keyring of the BackendMemory backend. Unlike
+// keyring.ArrayKeyring, it lists its keys in lexical order and fails to
+// remove a missing key, like the file backend does.
+type memoryKeyring struct {
+	*keyring.ArrayKeyring
+}
+
+func newMemoryKeyring(items []keyring.Item) memoryKeyring {
+	return memoryKeyring{keyring.NewArrayKeyring(items)}
+}
+
+func (k memoryKeyring) Keys() ([]string, error) {
+	keys, err := k.ArrayKeyring.Keys()
+	slices.Sort(keys)
+	return keys, err
+}
+
+func (k memoryKeyring) Remove(key string) error {
+	if _, err := k.ArrayKeyring.Get(key); err != nil {
+		return err
+	}
+	return k.ArrayKeyring.Remove(key)
 }
 
 func (k Keyring) Keys() ([]Key, error) {
@@ -65,6 +243,7 @@ func (k Keyring) Keys() ([]Key, error) {
 }
 
 func (k Keyring) Remove(name string) error {
+	name = strings.TrimSuffix(name, infoSuffix)
 	key, err := k.Get(name)
 	if err != nil {
 		return err
@@ -102,13 +281,13 @@ func (k Keyring) Get(name string) (Key, error) {
 
 	// try proto decode
 	var record cosmoskeyring.Record
-	errProto := codec.Proto.Unmarshal(item.Data, &record)
+	errProto := k.cdc.Proto.Unmarshal(item.Data, &record)
 	if errProto == nil {
-		return Key{name: name, record: &record}, nil
+		return Key{name: name, record: &record, ledger: k.ledger, cdc: k.cdc}, nil
 	}
 	// try amino decode
 	var info cosmoskeyring.LegacyInfo
-	errAmino := codec.Amino.UnmarshalLengthPrefixed(item.Data, &info)
+	errAmino := k.cdc.Amino.UnmarshalLengthPrefixed(item.Data, &info)
 	if errAmino == nil {
 		// After unmarshalling into &info, if we notice that the info is a
 		// multiInfo, then we unmarshal again, explicitly in a multiInfo this time.
@@ -122,11 +301,11 @@ func (k Keyring) Get(name string) (Key, error) {
 		_, ok := info.(legacyMultiInfo)
 		if ok {
 			var multi legacyMultiInfo
-			err = codec.Amino.UnmarshalLengthPrefixed(item.Data, &multi)
+			err = k.cdc.Amino.UnmarshalLengthPrefixed(item.Data, &multi)
 
-			return Key{name: name, info: multi}, err
+			return Key{name: name, info: withCodec(multi, k.cdc), ledger: k.ledger, cdc: k.cdc}, err
 		}
-		return Key{name: name, info: info}, nil
+		return Key{name: name, info: withCodec(info, k.cdc), ledger: k.ledger, cdc: k.cdc}, nil
 	}
 	return Key{}, fmt.Errorf("cannot decode key %s: decodeProto=%v decodeAmino=%v", name, errProto, errAmino)
 }
@@ -135,7 +314,11 @@ func (k Keyring) AddAmino(name string, info cosmoskeyring.LegacyInfo) error {
 	if !strings.HasSuffix(name, infoSuffix) {
 		name += infoSuffix
 	}
-	bz, err := codec.Amino.MarshalLengthPrefixed(info)
+	bz, err := k.cdc.Amino.MarshalLengthPrefixed(info)
+	if err != nil {
+		return err
+	}
+	addr, err := pubKeyAddress(k.cdc, info.GetPubKey())
 	if err != nil {
 		return err
 	}
@@ -143,14 +326,14 @@ func (k Keyring) AddAmino(name string, info cosmoskeyring.LegacyInfo) error {
 	if err != nil {
 		return err
 	}
-	return k.k.Set(keyring.Item{Key: addrHexKey(info.GetAddress()), Data: []byte(name)})
+	return k.k.Set(keyring.Item{Key: addrHexKey(addr), Data: []byte(name)})
 }
 
 func (k Keyring) AddProto(name string, record *cosmoskeyring.Record) error {
 	if !strings.HasSuffix(name, infoSuffix) {
 		name += infoSuffix
 	}
-	bz, err := codec.Proto.Marshal(record)
+	bz, err := k.cdc.Proto.Marshal(record)
 	if err != nil {
 		return err
 	}
@@ -164,5 +347,136 @@ func (k Keyring) AddProto(name string, record *cosmoskeyring.Record) error {
 	if !ok {
 		return fmt.Errorf("can't get pubkey from Record")
 	}
-	return k.k.Set(keyring.Item{Key: addrHexKey(sdk.AccAddress(pk.Address())), Data: []byte(name)})
+	addr, err := pubKeyAddress(k.cdc, pk)
+	if err != nil {
+		return err
+	}
+	return k.k.Set(keyring.Item{Key: addrHexKey(addr), Data: []byte(name)})
+}
+
+// Rename renames the key oldName into newName, it fails if a key named newName
+// already exists. Both the name embedded in the key and the address index
+// entry are updated, and the key keeps its encoding.
+//
+// If any write fails, the previous writes are reverted, so the key remains
+// available under oldName only.
+func (k Keyring) Rename(oldName, newName string) error {
+	oldName = strings.TrimSuffix(oldName, infoSuffix)
+	newName = strings.TrimSuffix(newName, infoSuffix)
+	key, err := k.Get(oldName)
+	if err != nil {
+		return err
+	}
+	if _, err := k.k.Get(newName + infoSuffix); err == nil {
+		return fmt.Errorf("%w: rename failed, %s", cosmoskeyring.ErrKeyAlreadyExists, newName)
+	}
+	addr, err := key.Address()
+	if err != nil {
+		return err
+	}
+	bz, err := key.marshalWithName(newName)
+	if err != nil {
+		return err
+	}
+	// Write newName.info
+	if err := k.k.Set(keyring.Item{Key: newName + infoSuffix, Data: bz}); err != nil {
+		return err
+	}
+	// Repoint <address>.address -> newName.info
+	err = k.k.Set(keyring.Item{Key: addrHexKey(addr), Data: []byte(newName + infoSuffix)})
+	if err != nil {
+		return errors.Join(err, k.k.Remove(newName+infoSuffix))
+	}
+	// Remove oldName.info
+	if err := k.k.Remove(oldName + infoSuffix); err != nil {
+		return errors.Join(err,
+			k.k.Set(keyring.Item{Key: addrHexKey(addr), Data: []byte(oldName + infoSuffix)}),
+			k.k.Remove(newName+infoSuffix),
+		)
+	}
+	return nil
+}
+
+// addRecord stores record under name with the given encoding, and returns the
+// resulting key. It fails if a key with the same name or address already
+// exists.
+func (k Keyring) addRecord(name string, record *cosmoskeyring.Record, encoding Encoding) (Key, error) {
+	name = strings.TrimSuffix(name, infoSuffix)
+	if _, err := k.k.Get(name + infoSuffix); err == nil {
+		return Key{}, fmt.Errorf("%w: %q", cosmoskeyring.ErrKeyAlreadyExists, name)
+	}
+	pk, err := record.GetPubKey()
+	if err != nil {
+		return Key{}, err
+	}
+	addr, err := pubKeyAddress(k.cdc, pk)
+	if err != nil {
+		return Key{}, err
+	}
+	if _, err := k.k.Get(addrHexKey(addr)); err == nil {
+		return Key{}, fmt.Errorf("%w: %s", cosmoskeyring.ErrDuplicatedAddress, addr)
+	}
+	switch encoding {
+	case ProtoEncoding:
+		err = k.AddProto(name, record)
+	case AminoEncoding:
+		var info cosmoskeyring.LegacyInfo
+		info, err = legacyInfoFromRecord(k.cdc, record)
+		if err != nil {
+			return Key{}, err
+		}
+		err = k.AddAmino(name, info)
+	default:
+		return Key{}, fmt.Errorf("unhandled encoding %q", encoding)
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	return k.Get(name)
+}
+
+// Snapshot returns all the raw items of the keyring, including the address
+// index entries and the items that are not keys. It can be used to seed a
+// memory keyring with the WithSnapshot option.
+func (k Keyring) Snapshot() ([]keyring.Item, error) {
+	keys, err := k.k.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("keyring.Keys: %w", err)
+	}
+	items := make([]keyring.Item, 0, len(keys))
+	for _, key := range keys {
+		item, err := k.k.Get(key)
+		if err != nil {
+			return nil, fmt.Errorf("keyring.Get %q: %w", key, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// restore makes the keyring content identical to items: items are written
+// back and keys that are not part of items are removed.
+func (k Keyring) restore(items []keyring.Item) error {
+	keys, err := k.k.Keys()
+	if err != nil {
+		return fmt.Errorf("keyring.Keys: %w", err)
+	}
+	snapshot := make(map[string]bool, len(items))
+	for _, item := range items {
+		snapshot[item.Key] = true
+	}
+	for _, key := range keys {
+		if snapshot[key] {
+			continue
+		}
+		if err := k.k.Remove(key); err != nil {
+			return fmt.Errorf("keyring.Remove %q: %w", key, err)
+		}
+	}
+	for _, item := range items {
+		if err := k.k.Set(item); err != nil {
+			return fmt.Errorf("keyring.Set %q: %w", item.Key, err)
+		}
+	}
+	return nil
 }
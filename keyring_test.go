@@ -2,26 +2,58 @@ package keyring_test
 
 import (
 	"encoding/hex"
+	"strings"
 	"testing"
 
+	bkeyring "github.com/99designs/keyring"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tbruyelle/keyring-compat"
+	"github.com/tbruyelle/keyring-compat/codec"
 
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
 	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
+// testPassword is the password func of the test file keyrings.
+func testPassword(string) (string, error) { return "test", nil }
+
+// newKeyring returns a file keyring in a temporary directory, configured
+// with opts.
+func newKeyring(t *testing.T, opts ...keyring.Option) keyring.Keyring {
+	t.Helper()
+	kr, err := keyring.New(keyring.BackendType("file"), t.TempDir(), testPassword, opts...)
+	require.NoError(t, err)
+	return kr
+}
+
+// addRecord adds record to kr with encoding, and returns the added key.
+func addRecord(t *testing.T, kr keyring.Keyring, record *cosmoskeyring.Record, encoding keyring.Encoding) keyring.Key {
+	t.Helper()
+	require := require.New(t)
+	require.NoError(kr.AddProto(record.Name, record))
+	key, err := kr.Get(record.Name)
+	require.NoError(err)
+	if encoding == keyring.ProtoEncoding {
+		return key
+	}
+	info, err := key.RecordToInfo()
+	require.NoError(err)
+	require.NoError(kr.Remove(record.Name))
+	require.NoError(kr.AddAmino(record.Name, info))
+	key, err = kr.Get(record.Name)
+	require.NoError(err)
+	return key
+}
+
 func TestKeyring(t *testing.T) {
 	//-----------------------------------------
 	// Setup
 	require := require.New(t)
 	assert := assert.New(t)
-	kr, err := keyring.New(keyring.BackendType("file"), t.TempDir(),
-		func(_ string) (string, error) { return "test", nil },
-	)
-	require.NoError(err)
+	kr := newKeyring(t)
 	// Generate a local private key
 	var (
 		privkey = ed25519.GenPrivKeyFromSecret([]byte("secret"))
@@ -55,6 +87,16 @@ func TestKeyring(t *testing.T) {
 	assert.True(aminoKey.IsAminoEncoded())
 	assert.False(protoKey.IsAminoEncoded())
 
+	//-----------------------------------------
+	// RecordToInfo() & InfoToRecord()
+	_, err = aminoKey.RecordToInfo()
+	assert.EqualError(err, `key "amino.info" is already amino encoded`)
+	_, err = protoKey.InfoToRecord()
+	assert.EqualError(err, `key "proto.info" is already proto encoded`)
+	record2, err := aminoKey.InfoToRecord()
+	require.NoError(err)
+	assert.Equal(record.String(), record2.String())
+
 	//-----------------------------------------
 	// Type()
 	assert.Equal("local", aminoKey.Type().String())
@@ -96,3 +138,122 @@ func TestKeyring(t *testing.T) {
 	_, err = kr.GetByAddress(sdk.AccAddress(pb.Address().Bytes()))
 	require.EqualError(err, "The specified item could not be found in the keyring")
 }
+
+func TestRename(t *testing.T) {
+	hdPath := hd.CreateHDPath(118, 0, 0).String()
+	for _, encoding := range []keyring.Encoding{keyring.AminoEncoding, keyring.ProtoEncoding} {
+		t.Run(string(encoding), func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			kr, _ := newLedgerKeyring(t)
+			_, err := kr.ImportMnemonic("local", testMnemonic, "", hdPath, hd.Secp256k1, encoding)
+			require.NoError(err)
+			_, err = kr.AddLedger("ledger", hd.NewFundraiserParams(1, 118, 0), encoding)
+			require.NoError(err)
+
+			err = kr.Rename("local", "ledger")
+			require.ErrorIs(err, cosmoskeyring.ErrKeyAlreadyExists)
+			err = kr.Rename("unknown", "other")
+			require.EqualError(err, "The specified item could not be found in the keyring")
+
+			for _, name := range []string{"local", "ledger"} {
+				key, err := kr.Get(name)
+				require.NoError(err)
+				newName := name + "-renamed"
+
+				err = kr.Rename(name, newName)
+
+				require.NoError(err)
+				_, err = kr.Get(name)
+				assert.EqualError(err, "The specified item could not be found in the keyring")
+				renamed, err := kr.Get(newName)
+				require.NoError(err)
+				assert.Equal(encoding, renamed.Encoding())
+				record, err := renamed.AsRecord()
+				require.NoError(err)
+				assert.Equal(newName, record.Name)
+				addr, err := key.Address()
+				require.NoError(err)
+				byAddr, err := kr.GetByAddress(addr)
+				require.NoError(err)
+				assert.Equal(renamed, byAddr)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	rootDir := t.TempDir()
+	// Create a key with the cosmos-sdk keyring
+	sdkKr, err := cosmoskeyring.New("gaia", cosmoskeyring.BackendTest, rootDir, nil, codec.Proto)
+	require.NoError(err)
+	record, err := sdkKr.NewAccount("sdk", testMnemonic, "", hd.CreateHDPath(118, 0, 0).String(), hd.Secp256k1)
+	require.NoError(err)
+	addr, err := record.GetAddress()
+	require.NoError(err)
+
+	kr, err := keyring.Open("gaia", keyring.BackendTest, rootDir, nil)
+
+	require.NoError(err)
+	key, err := kr.Get("sdk")
+	require.NoError(err)
+	assert.Equal(addr.String(), key.MustBech32Address("cosmos"))
+	assert.Equal("test", keyring.NewSDKKeyring(kr, keyring.ProtoEncoding).Backend())
+
+	_, err = keyring.Open("gaia", "unknown", rootDir, nil)
+	assert.EqualError(err, "unknown keyring backend: unknown")
+}
+
+func TestMemoryKeyring(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	src, _, _ := newMigrationKeyring(t)
+	snapshot, err := src.Snapshot()
+	require.NoError(err)
+
+	kr, err := keyring.New(keyring.BackendMemory, "", nil, keyring.WithSnapshot(snapshot))
+
+	require.NoError(err)
+	keys, err := kr.Keys()
+	require.NoError(err)
+	assert.Len(keys, 2)
+	for _, name := range []string{"local", "ledger"} {
+		want, err := src.Get(name)
+		require.NoError(err)
+		got, err := kr.Get(name)
+		require.NoError(err)
+		assert.NoError(keyring.CompareKeys(want, got))
+		addr, err := got.Address()
+		require.NoError(err)
+		_, err = kr.GetByAddress(addr)
+		assert.NoError(err)
+	}
+	// Writes don't leak into the seed nor the source keyring
+	require.NoError(kr.Remove("local"))
+	_, err = src.Get("local")
+	assert.NoError(err)
+	kr2, err := keyring.Open("gaia", keyring.BackendMemory, "", nil, keyring.WithSnapshot(snapshot))
+	require.NoError(err)
+	_, err = kr2.Get("local")
+	assert.NoError(err)
+	// Without a directory, migrations need an explicit target
+	_, err = kr2.MigrateProtoKeysToAmino()
+	assert.EqualError(err, "keyring has no directory for the migrated keys, use the WithTarget or WithInPlace option")
+	report, err := kr2.MigrateProtoKeysToAmino(keyring.WithTarget(newKeyring(t)))
+	require.NoError(err)
+	assert.NoError(report.Err())
+
+	//-----------------------------------------
+	// Remove() of a missing item fails like the file backend
+	var withoutAddr []bkeyring.Item
+	for _, item := range snapshot {
+		if strings.HasSuffix(item.Key, ".info") {
+			withoutAddr = append(withoutAddr, item)
+		}
+	}
+	kr, err = keyring.New(keyring.BackendMemory, "", nil, keyring.WithSnapshot(withoutAddr))
+	require.NoError(err)
+	assert.ErrorIs(kr.Remove("local"), bkeyring.ErrKeyNotFound)
+}
@@ -3,22 +3,167 @@ package keyring
 import (
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
 
 	ledger "github.com/cosmos/ledger-cosmos-go"
 
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 )
 
-func signWithLedger(device *ledger.LedgerCosmos, k Key, bzToSign []byte) ([]byte, error) {
+// LedgerDevice is the subset of the Cosmos ledger app API used by the keyring.
+// It is implemented by *ledger.LedgerCosmos.
+type LedgerDevice interface {
+	// SignSECP256K1 signs transaction with the key at bip32Path, it requires
+	// the user confirmation on the device. The returned signature is DER
+	// encoded.
+	SignSECP256K1(bip32Path []uint32, transaction []byte, p2 byte) ([]byte, error)
+	// GetPublicKeySECP256K1 returns the compressed public key at bip32Path.
+	GetPublicKeySECP256K1(bip32Path []uint32) ([]byte, error)
+	// GetAddressPubKeySECP256K1 returns the compressed public key and the
+	// bech32 address at bip32Path, and shows the address on the device.
+	GetAddressPubKeySECP256K1(bip32Path []uint32, hrp string) ([]byte, string, error)
+	// GetVersion returns the version of the Cosmos app.
+	GetVersion() (*ledger.VersionInfo, error)
+}
+
+// textualMinVersion is the first version of the Cosmos ledger app that
+// supports SIGN_MODE_TEXTUAL. Older v2 apps accept the P2 sign mode parameter
+// but reject P2=1, so the version is checked before sending the request to
+// report an explicit "app too old" error.
+var textualMinVersion = ledger.VersionInfo{Major: 2, Minor: 34}
+
+var _ LedgerDevice = (*ledger.LedgerCosmos)(nil)
+
+// hidLedger is the default LedgerDevice, it connects to the first ledger
+// device found with the Cosmos app open, for each call.
+type hidLedger struct{}
+
+func (hidLedger) SignSECP256K1(bip32Path []uint32, transaction []byte, p2 byte) ([]byte, error) {
+	device, err := ledger.FindLedgerCosmosUserApp()
+	if err != nil {
+		return nil, err
+	}
+	defer device.Close()
+	return device.SignSECP256K1(bip32Path, transaction, p2)
+}
+
+func (hidLedger) GetPublicKeySECP256K1(bip32Path []uint32) ([]byte, error) {
+	device, err := ledger.FindLedgerCosmosUserApp()
+	if err != nil {
+		return nil, err
+	}
+	defer device.Close()
+	return device.GetPublicKeySECP256K1(bip32Path)
+}
+
+func (hidLedger) GetAddressPubKeySECP256K1(bip32Path []uint32, hrp string) ([]byte, string, error) {
+	device, err := ledger.FindLedgerCosmosUserApp()
+	if err != nil {
+		return nil, "", err
+	}
+	defer device.Close()
+	return device.GetAddressPubKeySECP256K1(bip32Path, hrp)
+}
+
+func (hidLedger) GetVersion() (*ledger.VersionInfo, error) {
+	device, err := ledger.FindLedgerCosmosUserApp()
+	if err != nil {
+		return nil, err
+	}
+	defer device.Close()
+	return device.GetVersion()
+}
+
+// LedgerMismatchError is returned when the public key of a ledger key doesn't
+// match the public key of the connected ledger device, which usually means
+// that the wrong device is connected.
+type LedgerMismatchError struct {
+	Path           *hd.BIP44Params
+	ExpectedPubKey cryptotypes.PubKey
+	DevicePubKey   cryptotypes.PubKey
+}
+
+func (e *LedgerMismatchError) Error() string {
+	return fmt.Sprintf("ledger device pubkey %s at path %s doesn't match key pubkey %s, is the right device connected?",
+		e.DevicePubKey, e.Path, e.ExpectedPubKey)
+}
+
+// CheckLedgerDevice ensures that the connected ledger device holds k, by
+// comparing the public key of k with the one of the device at the BIP44 path
+// of k. If they don't match, a *LedgerMismatchError is returned.
+func (k Key) CheckLedgerDevice() error {
+	return checkLedgerDevice(k.ledgerDevice(), k)
+}
+
+func checkLedgerDevice(device LedgerDevice, k Key) error {
+	if k.Type() != cosmoskeyring.TypeLedger {
+		return fmt.Errorf("key %q is not a ledger key", k.name)
+	}
+	path, err := k.getBip44Path()
+	if err != nil {
+		return fmt.Errorf("getBip44Path: %w", err)
+	}
+	pubKey, err := k.PubKey()
+	if err != nil {
+		return err
+	}
+	devicePubKey, err := getLedgerPubKey(device, path.DerivationPath())
+	if err != nil {
+		return fmt.Errorf("getLedgerPubKey: %w", err)
+	}
+	if !pubKey.Equals(devicePubKey) {
+		return &LedgerMismatchError{
+			Path:           path,
+			ExpectedPubKey: pubKey,
+			DevicePubKey:   devicePubKey,
+		}
+	}
+	return nil
+}
+
+// ledgerP2 returns the P2 parameter of the ledger sign instruction that
+// matches signMode. It fails if signMode is not supported by the device app.
+func ledgerP2(device LedgerDevice, signMode signing.SignMode) (byte, error) {
+	switch signMode {
+	case signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON:
+		return 0, nil
+
+	case signing.SignMode_SIGN_MODE_TEXTUAL:
+		version, err := device.GetVersion()
+		if err != nil {
+			return 0, fmt.Errorf("GetVersion: %w", err)
+		}
+		if err := ledger.CheckVersion(*version, textualMinVersion); err != nil {
+			return 0, fmt.Errorf("sign mode %s not supported by the ledger app: %w", signMode, err)
+		}
+		return 1, nil
+	}
+	return 0, fmt.Errorf("%w, got %s", cosmoskeyring.ErrInvalidSignMode, signMode)
+}
+
+func signWithLedger(device LedgerDevice, k Key, bzToSign []byte, signMode signing.SignMode) ([]byte, error) {
 	path, err := k.getBip44Path()
 	if err != nil {
 		return nil, fmt.Errorf("getBip44Path: %w", err)
 	}
-	signature, err := device.SignSECP256K1(path.DerivationPath(), bzToSign, 0)
+	p2, err := ledgerP2(device, signMode)
+	if err != nil {
+		return nil, err
+	}
+	// Ensure the device holds the key before asking the user to confirm the
+	// signature on the device.
+	if err := checkLedgerDevice(device, k); err != nil {
+		return nil, err
+	}
+	signature, err := device.SignSECP256K1(path.DerivationPath(), bzToSign, p2)
 	if err != nil {
 		return nil, fmt.Errorf("SignSECP256K1: %w", err)
 	}
@@ -29,7 +174,7 @@ func signWithLedger(device *ledger.LedgerCosmos, k Key, bzToSign []byte) ([]byte
 	return signature, nil
 }
 
-func getLedgerPubKey(device *ledger.LedgerCosmos, bip32Path []uint32) (cryptotypes.PubKey, error) {
+func getLedgerPubKey(device LedgerDevice, bip32Path []uint32) (cryptotypes.PubKey, error) {
 	pubKey, err := device.GetPublicKeySECP256K1(bip32Path)
 	if err != nil {
 		return nil, err
@@ -73,3 +218,64 @@ func convertDERtoBER(signatureDER []byte) ([]byte, error) {
 
 	return sigBytes, nil
 }
+
+// AddLedgerOption configures Keyring.AddLedger.
+type AddLedgerOption func(*addLedgerOptions)
+
+type addLedgerOptions struct {
+	hrp string
+}
+
+// WithAddressConfirmation shows the address of the key on the ledger device,
+// with the bech32 prefix hrp, and requires the user to confirm it before the
+// key is created.
+func WithAddressConfirmation(hrp string) AddLedgerOption {
+	return func(o *addLedgerOptions) {
+		o.hrp = hrp
+	}
+}
+
+// AddLedger creates a ledger key named name, which public key is read from
+// the ledger device at the given BIP44 path. The key is stored using
+// encoding, along with its address index entry.
+func (k Keyring) AddLedger(name string, path *hd.BIP44Params, encoding Encoding, opts ...AddLedgerOption) (Key, error) {
+	var o addLedgerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	pubKey, err := getLedgerPubKey(k.ledger, path.DerivationPath())
+	if err != nil {
+		return Key{}, fmt.Errorf("getLedgerPubKey: %w", err)
+	}
+	if o.hrp != "" {
+		if err := k.confirmLedgerAddress(path, pubKey, o.hrp); err != nil {
+			return Key{}, err
+		}
+	}
+	record, err := cosmoskeyring.NewLedgerRecord(strings.TrimSuffix(name, infoSuffix), pubKey, path)
+	if err != nil {
+		return Key{}, err
+	}
+	return k.addRecord(name, record, encoding)
+}
+
+// confirmLedgerAddress shows the address of pubKey on the ledger device and
+// ensures the device displayed the same address.
+func (k Keyring) confirmLedgerAddress(path *hd.BIP44Params, pubKey cryptotypes.PubKey, hrp string) error {
+	_, deviceAddr, err := k.ledger.GetAddressPubKeySECP256K1(path.DerivationPath(), hrp)
+	if err != nil {
+		return fmt.Errorf("GetAddressPubKeySECP256K1: %w", err)
+	}
+	addr, err := pubKeyAddress(k.cdc, pubKey)
+	if err != nil {
+		return err
+	}
+	bech32Addr, err := bech32.ConvertAndEncode(hrp, addr)
+	if err != nil {
+		return err
+	}
+	if deviceAddr != bech32Addr {
+		return fmt.Errorf("ledger device address %s doesn't match key address %s", deviceAddr, bech32Addr)
+	}
+	return nil
+}
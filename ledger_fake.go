@@ -0,0 +1,149 @@
+package keyring
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+
+	ledger "github.com/cosmos/ledger-cosmos-go"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// errFakeLedgerRejected is the error returned by the ledger app when the user
+// rejects the request on the device.
+var errFakeLedgerRejected = errors.New("[APDU_CODE_COMMAND_NOT_ALLOWED] Command not allowed / User Rejected (no current EF)")
+
+// errFakeLedgerInvalidP2 is the error returned by the ledger app when the P2
+// sign mode parameter is not supported.
+var errFakeLedgerInvalidP2 = errors.New("[APDU_CODE_DATA_INVALID] Referenced data reversibly blocked (invalidated)")
+
+// FakeLedger is an in-memory LedgerDevice that derives its keys from a
+// mnemonic, like a real ledger device does. It is intended for testing.
+type FakeLedger struct {
+	// Reject simulates the user rejecting the requests on the device.
+	Reject bool
+	// Version is the version of the Cosmos app returned by GetVersion.
+	Version ledger.VersionInfo
+	// HighS makes SignSECP256K1 return signatures with a high S value, which
+	// the ledger app may do since it doesn't normalize them.
+	HighS bool
+
+	mnemonic string
+}
+
+var _ LedgerDevice = (*FakeLedger)(nil)
+
+// NewFakeLedger returns a FakeLedger which keys are derived from mnemonic.
+// The app version is set to the first version that supports all sign modes.
+func NewFakeLedger(mnemonic string) *FakeLedger {
+	return &FakeLedger{mnemonic: mnemonic, Version: textualMinVersion}
+}
+
+// SignSECP256K1 implements LedgerDevice. Like the ledger app, the signature
+// is DER encoded.
+func (l *FakeLedger) SignSECP256K1(bip32Path []uint32, transaction []byte, p2 byte) ([]byte, error) {
+	// Like the ledger app, P2 is only used since v2, and SIGN_MODE_TEXTUAL
+	// (P2=1) only since textualMinVersion.
+	if l.Version.Major >= 2 {
+		if p2 > 1 || (p2 == 1 && ledger.CheckVersion(l.Version, textualMinVersion) != nil) {
+			return nil, errFakeLedgerInvalidP2
+		}
+	}
+	if l.Reject {
+		return nil, errFakeLedgerRejected
+	}
+	privKey, err := l.derive(bip32Path)
+	if err != nil {
+		return nil, err
+	}
+	priv, _ := btcec.PrivKeyFromBytes(privKey.Key)
+	hash := sha256.Sum256(transaction)
+	sig := ecdsa.Sign(priv, hash[:]).Serialize()
+	if l.HighS {
+		sig = highSDER(sig)
+	}
+	return sig, nil
+}
+
+// highSDER returns the low-S DER signature sig with its S value replaced by
+// N - S.
+func highSDER(sig []byte) []byte {
+	// 0x30 <total length> 0x02 <length of R> <R> 0x02 <length of S> <S>
+	r := new(big.Int).SetBytes(sig[4 : 4+sig[3]])
+	s := new(big.Int).SetBytes(sig[4+sig[3]+2:])
+	s.Sub(btcec.S256().N, s)
+	derInt := func(i *big.Int) []byte {
+		bz := i.Bytes()
+		if bz[0]&0x80 != 0 {
+			// the high bit would make the integer negative
+			bz = append([]byte{0}, bz...)
+		}
+		return append([]byte{0x02, byte(len(bz))}, bz...)
+	}
+	body := append(derInt(r), derInt(s)...)
+	return append([]byte{0x30, byte(len(body))}, body...)
+}
+
+// GetVersion implements LedgerDevice.
+func (l *FakeLedger) GetVersion() (*ledger.VersionInfo, error) {
+	version := l.Version
+	return &version, nil
+}
+
+// GetPublicKeySECP256K1 implements LedgerDevice.
+func (l *FakeLedger) GetPublicKeySECP256K1(bip32Path []uint32) ([]byte, error) {
+	privKey, err := l.derive(bip32Path)
+	if err != nil {
+		return nil, err
+	}
+	return privKey.PubKey().Bytes(), nil
+}
+
+// GetAddressPubKeySECP256K1 implements LedgerDevice.
+func (l *FakeLedger) GetAddressPubKeySECP256K1(bip32Path []uint32, hrp string) ([]byte, string, error) {
+	if l.Reject {
+		return nil, "", errFakeLedgerRejected
+	}
+	privKey, err := l.derive(bip32Path)
+	if err != nil {
+		return nil, "", err
+	}
+	pubKey := privKey.PubKey()
+	addr, err := bech32.ConvertAndEncode(hrp, pubKey.Address())
+	if err != nil {
+		return nil, "", err
+	}
+	return pubKey.Bytes(), addr, nil
+}
+
+func (l *FakeLedger) derive(bip32Path []uint32) (*secp256k1.PrivKey, error) {
+	bz, err := hd.Secp256k1.Derive()(l.mnemonic, "", bip32PathString(bip32Path))
+	if err != nil {
+		return nil, fmt.Errorf("derive: %w", err)
+	}
+	return &secp256k1.PrivKey{Key: bz}, nil
+}
+
+// bip32PathString returns the string representation of bip32Path, for
+// instance m/44'/118'/0'/0/0.
+func bip32PathString(bip32Path []uint32) string {
+	const hardened = 0x80000000
+	var b strings.Builder
+	b.WriteString("m")
+	for _, p := range bip32Path {
+		if p >= hardened {
+			fmt.Fprintf(&b, "/%d'", p-hardened)
+		} else {
+			fmt.Fprintf(&b, "/%d", p)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,276 @@
+package keyring_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+
+	ledger "github.com/cosmos/ledger-cosmos-go"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func newLedgerKeyring(t *testing.T) (keyring.Keyring, *keyring.FakeLedger) {
+	t.Helper()
+	device := keyring.NewFakeLedger(testMnemonic)
+	return newKeyring(t, keyring.WithLedger(device)), device
+}
+
+func TestSignWithLedger(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	kr, device := newLedgerKeyring(t)
+	path := hd.NewFundraiserParams(0, 118, 0)
+	protoKey, err := kr.AddLedger("proto", path, keyring.ProtoEncoding)
+	require.NoError(err)
+	aminoKey, err := kr.AddLedger("amino", hd.NewFundraiserParams(1, 118, 0), keyring.AminoEncoding)
+	require.NoError(err)
+	msg := []byte("hello world")
+
+	for _, key := range []keyring.Key{protoKey, aminoKey} {
+		pubKey, err := key.PubKey()
+		require.NoError(err)
+
+		device.Reject = false
+		signature, err := key.Sign(msg)
+
+		require.NoError(err)
+		assert.True(pubKey.VerifySignature(msg, signature), "invalid signature")
+
+		device.Reject = true
+		_, err = key.Sign(msg)
+
+		assert.ErrorContains(err, "User Rejected")
+	}
+}
+
+func TestSignWithLedgerHighS(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	kr, device := newLedgerKeyring(t)
+	key, err := kr.AddLedger("ledger", hd.NewFundraiserParams(0, 118, 0), keyring.ProtoEncoding)
+	require.NoError(err)
+	pubKey, err := key.PubKey()
+	require.NoError(err)
+	var (
+		msg      = []byte("hello world")
+		halfN    = new(big.Int).Rsh(btcec.S256().N, 1)
+		hardened = uint32(0x80000000)
+	)
+	device.HighS = true
+	// The device returns a high-S DER signature
+	der, err := device.SignSECP256K1([]uint32{44 + hardened, 118 + hardened, hardened, 0, 0}, msg, 0)
+	require.NoError(err)
+	// 0x30 <total length> 0x02 <length of R> <R> 0x02 <length of S> <S>
+	require.Equal(1, new(big.Int).SetBytes(der[4+der[3]+2:]).Cmp(halfN))
+
+	signature, err := key.Sign(msg)
+
+	require.NoError(err)
+	require.Len(signature, 64)
+	assert.NotEqual(1, new(big.Int).SetBytes(signature[32:]).Cmp(halfN), "S is not normalized")
+	assert.True(pubKey.VerifySignature(msg, signature), "invalid signature")
+}
+
+func TestAddLedger(t *testing.T) {
+	kr, device := newLedgerKeyring(t)
+	tests := []struct {
+		name     string
+		path     *hd.BIP44Params
+		encoding keyring.Encoding
+	}{
+		{
+			name:     "proto",
+			path:     hd.NewFundraiserParams(0, 118, 0),
+			encoding: keyring.ProtoEncoding,
+		},
+		{
+			name:     "amino",
+			path:     hd.NewFundraiserParams(1, 118, 0),
+			encoding: keyring.AminoEncoding,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+
+			key, err := kr.AddLedger(tt.name, tt.path, tt.encoding)
+
+			require.NoError(err)
+			assert.Equal(cosmoskeyring.TypeLedger, key.Type())
+			assert.Equal(tt.encoding, key.Encoding())
+			bz, err := device.GetPublicKeySECP256K1(tt.path.DerivationPath())
+			require.NoError(err)
+			pubKey, err := key.PubKey()
+			require.NoError(err)
+			assert.Equal(bz, pubKey.Bytes())
+			addr, err := key.Address()
+			require.NoError(err)
+			key2, err := kr.GetByAddress(addr)
+			require.NoError(err)
+			assert.Equal(key, key2)
+			// A second call must fail
+			_, err = kr.AddLedger(tt.name, tt.path, tt.encoding)
+			assert.ErrorIs(err, cosmoskeyring.ErrKeyAlreadyExists)
+			_, err = kr.AddLedger(tt.name+"2", tt.path, tt.encoding)
+			assert.ErrorIs(err, cosmoskeyring.ErrDuplicatedAddress)
+		})
+	}
+
+	t.Run("address confirmation", func(t *testing.T) {
+		require := require.New(t)
+		assert := assert.New(t)
+		path := hd.NewFundraiserParams(2, 118, 0)
+		device.Reject = true
+
+		_, err := kr.AddLedger("confirmed", path, keyring.ProtoEncoding, keyring.WithAddressConfirmation("cosmos"))
+
+		assert.ErrorContains(err, "User Rejected")
+		_, err = kr.Get("confirmed")
+		assert.EqualError(err, "The specified item could not be found in the keyring")
+		device.Reject = false
+		key, err := kr.AddLedger("confirmed", path, keyring.ProtoEncoding, keyring.WithAddressConfirmation("cosmos"))
+		require.NoError(err)
+		_, deviceAddr, err := device.GetAddressPubKeySECP256K1(path.DerivationPath(), "cosmos")
+		require.NoError(err)
+		assert.Equal(deviceAddr, key.MustBech32Address("cosmos"))
+	})
+}
+
+func TestCheckLedgerDevice(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		dir         = t.TempDir()
+		device      = keyring.NewFakeLedger(testMnemonic)
+		otherDevice = keyring.NewFakeLedger("zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong")
+	)
+	kr, err := keyring.New(keyring.BackendType("file"), dir, testPassword, keyring.WithLedger(device))
+	require.NoError(err)
+	key, err := kr.AddLedger("ledger", hd.NewFundraiserParams(0, 118, 0), keyring.ProtoEncoding)
+	require.NoError(err)
+	// Open the same keyring with an other ledger device
+	otherKr, err := keyring.New(keyring.BackendType("file"), dir, testPassword, keyring.WithLedger(otherDevice))
+	require.NoError(err)
+	otherKey, err := otherKr.Get("ledger")
+	require.NoError(err)
+
+	require.NoError(key.CheckLedgerDevice())
+	err = otherKey.CheckLedgerDevice()
+	var mismatchErr *keyring.LedgerMismatchError
+	require.ErrorAs(err, &mismatchErr)
+	assert.Equal("m/44'/118'/0'/0/0", mismatchErr.Path.String())
+	// Sign must fail the same way
+	_, err = otherKey.Sign([]byte("hello world"))
+	assert.ErrorAs(err, &mismatchErr)
+}
+
+func TestSignWithLedgerSignMode(t *testing.T) {
+	kr, device := newLedgerKeyring(t)
+	key, err := kr.AddLedger("ledger", hd.NewFundraiserParams(0, 118, 0), keyring.ProtoEncoding)
+	require.NoError(t, err)
+	tests := []struct {
+		name          string
+		version       ledger.VersionInfo
+		signMode      signing.SignMode
+		expectedError string
+	}{
+		{
+			name:     "amino-json",
+			version:  ledger.VersionInfo{Major: 1, Minor: 5},
+			signMode: signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON,
+		},
+		{
+			name:     "textual",
+			version:  ledger.VersionInfo{Major: 2, Minor: 34},
+			signMode: signing.SignMode_SIGN_MODE_TEXTUAL,
+		},
+		{
+			name:          "textual with old v2 app",
+			version:       ledger.VersionInfo{Major: 2, Minor: 12},
+			signMode:      signing.SignMode_SIGN_MODE_TEXTUAL,
+			expectedError: "sign mode SIGN_MODE_TEXTUAL not supported by the ledger app: App Version required 2.34.0 - Version found: 2.12.0",
+		},
+		{
+			name:          "textual with v1 app",
+			version:       ledger.VersionInfo{Major: 1, Minor: 5},
+			signMode:      signing.SignMode_SIGN_MODE_TEXTUAL,
+			expectedError: "sign mode SIGN_MODE_TEXTUAL not supported by the ledger app: App Version required 2.34.0 - Version found: 1.5.0",
+		},
+		{
+			name:          "direct",
+			version:       ledger.VersionInfo{Major: 2, Minor: 12},
+			signMode:      signing.SignMode_SIGN_MODE_DIRECT,
+			expectedError: "invalid sign mode, expected LEGACY_AMINO_JSON or TEXTUAL, got SIGN_MODE_DIRECT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device.Version = tt.version
+
+			_, err := key.Sign([]byte("hello world"), keyring.WithSignMode(tt.signMode))
+
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestFakeLedgerSignMode(t *testing.T) {
+	device := keyring.NewFakeLedger(testMnemonic)
+	path := []uint32{44 + 0x80000000, 118 + 0x80000000, 0x80000000, 0, 0}
+	tests := []struct {
+		name          string
+		version       ledger.VersionInfo
+		p2            byte
+		expectedError string
+	}{
+		{
+			name:    "textual",
+			version: ledger.VersionInfo{Major: 2, Minor: 34},
+			p2:      1,
+		},
+		{
+			name:          "textual with old v2 app",
+			version:       ledger.VersionInfo{Major: 2, Minor: 12},
+			p2:            1,
+			expectedError: "[APDU_CODE_DATA_INVALID] Referenced data reversibly blocked (invalidated)",
+		},
+		{
+			name:    "P2 ignored by v1 app",
+			version: ledger.VersionInfo{Major: 1, Minor: 5},
+			p2:      2,
+		},
+		{
+			name:          "unknown P2",
+			version:       ledger.VersionInfo{Major: 2, Minor: 34},
+			p2:            2,
+			expectedError: "[APDU_CODE_DATA_INVALID] Referenced data reversibly blocked (invalidated)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device.Version = tt.version
+
+			_, err := device.SignSECP256K1(path, []byte("hello world"), tt.p2)
+
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/tbruyelle/keyring-compat/codec"
 
+	cosmoscodec "github.com/cosmos/cosmos-sdk/codec"
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	"github.com/cosmos/cosmos-sdk/crypto/hd"
 	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
@@ -23,12 +24,19 @@ var (
 )
 
 func init() {
-	codec.Amino.RegisterInterface((*cosmoskeyring.LegacyInfo)(nil), nil)
-	codec.Amino.RegisterConcrete(hd.BIP44Params{}, "crypto/keys/hd/BIP44Params", nil)
-	codec.Amino.RegisterConcrete(legacyLocalInfo{}, "crypto/keys/localInfo", nil)
-	codec.Amino.RegisterConcrete(legacyLedgerInfo{}, "crypto/keys/ledgerInfo", nil)
-	codec.Amino.RegisterConcrete(legacyOfflineInfo{}, "crypto/keys/offlineInfo", nil)
-	codec.Amino.RegisterConcrete(legacyMultiInfo{}, "crypto/keys/multiInfo", nil)
+	// codec.Default is created before this package is initialized
+	registerLegacyInfo(codec.Default.Amino)
+	codec.RegisterAminoTypes(registerLegacyInfo)
+}
+
+// registerLegacyInfo registers the LegacyInfo types into amino.
+func registerLegacyInfo(amino *cosmoscodec.LegacyAmino) {
+	amino.RegisterInterface((*cosmoskeyring.LegacyInfo)(nil), nil)
+	amino.RegisterConcrete(hd.BIP44Params{}, "crypto/keys/hd/BIP44Params", nil)
+	amino.RegisterConcrete(legacyLocalInfo{}, "crypto/keys/localInfo", nil)
+	amino.RegisterConcrete(legacyLedgerInfo{}, "crypto/keys/ledgerInfo", nil)
+	amino.RegisterConcrete(legacyOfflineInfo{}, "crypto/keys/offlineInfo", nil)
+	amino.RegisterConcrete(legacyMultiInfo{}, "crypto/keys/multiInfo", nil)
 }
 
 // legacyLocalInfo is the public information about a locally stored key
@@ -38,6 +46,9 @@ type legacyLocalInfo struct {
 	PubKey       cryptotypes.PubKey `json:"pubkey"`
 	PrivKeyArmor string             `json:"privkey.armor"`
 	Algo         hd.PubKeyType      `json:"algo"`
+
+	// cdc derives the address, it is not encoded.
+	cdc *codec.Codec
 }
 
 // GetType implements Info interface
@@ -55,9 +66,10 @@ func (i legacyLocalInfo) GetPubKey() cryptotypes.PubKey {
 	return i.PubKey
 }
 
-// GetType implements Info interface
+// GetAddress implements Info interface, it returns nil if the address can't be
+// derived, see Key.Address for the error.
 func (i legacyLocalInfo) GetAddress() sdk.AccAddress {
-	return i.PubKey.Address().Bytes()
+	return legacyAddress(i.cdc, i.PubKey)
 }
 
 // GetPrivKeyArmor
@@ -82,6 +94,9 @@ type legacyLedgerInfo struct {
 	PubKey cryptotypes.PubKey `json:"pubkey"`
 	Path   hd.BIP44Params     `json:"path"`
 	Algo   hd.PubKeyType      `json:"algo"`
+
+	// cdc derives the address, it is not encoded.
+	cdc *codec.Codec
 }
 
 // GetType implements Info interface
@@ -99,9 +114,10 @@ func (i legacyLedgerInfo) GetPubKey() cryptotypes.PubKey {
 	return i.PubKey
 }
 
-// GetAddress implements Info interface
+// GetAddress implements Info interface, it returns nil if the address can't be
+// derived, see Key.Address for the error.
 func (i legacyLedgerInfo) GetAddress() sdk.AccAddress {
-	return i.PubKey.Address().Bytes()
+	return legacyAddress(i.cdc, i.PubKey)
 }
 
 // GetPath implements Info interface
@@ -121,6 +137,9 @@ type legacyOfflineInfo struct {
 	Name   string             `json:"name"`
 	PubKey cryptotypes.PubKey `json:"pubkey"`
 	Algo   hd.PubKeyType      `json:"algo"`
+
+	// cdc derives the address, it is not encoded.
+	cdc *codec.Codec
 }
 
 // GetType implements Info interface
@@ -143,9 +162,10 @@ func (i legacyOfflineInfo) GetAlgo() hd.PubKeyType {
 	return i.Algo
 }
 
-// GetAddress implements Info interface
+// GetAddress implements Info interface, it returns nil if the address can't be
+// derived, see Key.Address for the error.
 func (i legacyOfflineInfo) GetAddress() sdk.AccAddress {
-	return i.PubKey.Address().Bytes()
+	return legacyAddress(i.cdc, i.PubKey)
 }
 
 // GetPath implements Info interface
@@ -159,6 +179,9 @@ type legacyMultiInfo struct {
 	PubKey    cryptotypes.PubKey   `json:"pubkey"`
 	Threshold uint                 `json:"threshold"`
 	PubKeys   []multisigPubKeyInfo `json:"pubkeys"`
+
+	// cdc derives the address, it is not encoded.
+	cdc *codec.Codec
 }
 
 type multisigPubKeyInfo struct {
@@ -181,9 +204,10 @@ func (i legacyMultiInfo) GetPubKey() cryptotypes.PubKey {
 	return i.PubKey
 }
 
-// GetAddress implements Info interface
+// GetAddress implements Info interface, it returns nil if the address can't be
+// derived, see Key.Address for the error.
 func (i legacyMultiInfo) GetAddress() sdk.AccAddress {
-	return i.PubKey.Address().Bytes()
+	return legacyAddress(i.cdc, i.PubKey)
 }
 
 // GetPath implements Info interface
@@ -202,3 +226,36 @@ func (i legacyMultiInfo) UnpackInterfaces(unpacker codectypes.AnyUnpacker) error
 
 	return codectypes.UnpackInterfaces(multiPK, unpacker)
 }
+
+// legacyAddress returns the address of pk with cdc, or with the default codec
+// set if cdc is nil. It returns nil if the address can't be derived, see
+// codec.Address.
+func legacyAddress(cdc *codec.Codec, pk cryptotypes.PubKey) sdk.AccAddress {
+	if cdc == nil {
+		cdc = codec.Default
+	}
+	addr, err := pubKeyAddress(cdc, pk)
+	if err != nil {
+		return nil
+	}
+	return addr
+}
+
+// withCodec returns a copy of info which address is derived with cdc.
+func withCodec(info cosmoskeyring.LegacyInfo, cdc *codec.Codec) cosmoskeyring.LegacyInfo {
+	switch i := info.(type) {
+	case legacyLocalInfo:
+		i.cdc = cdc
+		return i
+	case legacyLedgerInfo:
+		i.cdc = cdc
+		return i
+	case legacyOfflineInfo:
+		i.cdc = cdc
+		return i
+	case legacyMultiInfo:
+		i.cdc = cdc
+		return i
+	}
+	return info
+}
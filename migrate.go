@@ -1,15 +1,122 @@
 package keyring
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/99designs/keyring"
-	"github.com/davecgh/go-spew/spew"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// MigrationAction is the action taken on a key during a migration.
+type MigrationAction string
+
+const (
+	// MigrationSkipped means the key was already in the target encoding, or
+	// was not selected by the migration filter.
+	MigrationSkipped MigrationAction = "skipped"
+	// MigrationMigrated means the key was re-encoded in the target keyring (or
+	// would have been in dry-run mode).
+	MigrationMigrated MigrationAction = "migrated"
+	// MigrationRolledBack means the key was migrated in-place, but the
+	// keyring has been restored after the failed migration of another key.
+	MigrationRolledBack MigrationAction = "rolled back"
+	// MigrationFailed means the key couldn't be migrated, see
+	// MigrationResult.Err for the reason.
+	MigrationFailed MigrationAction = "failed"
+	// MigrationNotAttempted means the in-place migration failed before
+	// reaching the key, which has been left untouched.
+	MigrationNotAttempted MigrationAction = "not attempted"
+	// MigrationUnexpected means the key is in the target keyring but not in
+	// the source keyring. Keys that were already in the target keyring before
+	// the migration are left untouched and reported without error.
+	MigrationUnexpected MigrationAction = "unexpected"
 )
 
+// errUnexpectedKey is the error of the MigrationUnexpected results for keys
+// that appeared in the target keyring during the migration.
+var errUnexpectedKey = errors.New("key is not in the source keyring")
+
+// MigrationResult is the outcome of the migration of a single key.
+type MigrationResult struct {
+	Name    string
+	Address sdk.AccAddress
+	// Encoding is the encoding of the key in the source keyring, empty if the
+	// key couldn't be decoded.
+	Encoding Encoding
+	Action   MigrationAction
+	Err      error
+}
+
+// MigrationReport lists the outcome of a migration, one entry per key.
+type MigrationReport []MigrationResult
+
+// Err returns the joined errors of the failed migrations, nil if all keys
+// were skipped or migrated.
+func (r MigrationReport) Err() error {
+	var errs []error
+	for _, res := range r {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Name, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MigrateOption configures a migration.
+type MigrateOption func(*migrateOptions)
+
+type migrateOptions struct {
+	dryRun bool
+	target *Keyring
+	backup *Keyring
+	filter Filter
+}
+
+// WithDryRun computes the migration report without writing anything.
+func WithDryRun() MigrateOption {
+	return func(o *migrateOptions) {
+		o.dryRun = true
+	}
+}
+
+// WithTarget sets the keyring where the migrated keys are written. This allows
+// for instance to migrate a test keyring into a file keyring, or to provide
+// the password function of the target keyring.
+func WithTarget(target Keyring) MigrateOption {
+	return func(o *migrateOptions) {
+		o.target = &target
+	}
+}
+
+// WithFilter restricts the migration to the keys selected by filter. The
+// other keys are left untouched and reported as skipped.
+func WithFilter(filter Filter) MigrateOption {
+	return func(o *migrateOptions) {
+		o.filter = filter
+	}
+}
+
+// WithInPlace rewrites the migrated keys in the source keyring instead of a
+// separate one. Before any write, all the items of the source keyring are
+// copied into backup, and if the migration of a key fails, the source keyring
+// is restored to its initial state. The keys that come after the failed key
+// are then reported as not attempted.
+func WithInPlace(backup Keyring) MigrateOption {
+	return func(o *migrateOptions) {
+		o.backup = &backup
+	}
+}
+
 // MigrateProtoKeysToAmino turns all proto encoded keys from kr and migrate
-// them to amino format, in a new keyring located in kr.dir/amino.
+// them to amino format, in a new keyring located in kr.dir/amino. The
+// location of the new keyring can be changed with the WithTarget option,
+// else it is a file keyring which password is prompted on stdin.
 //
 // This function is useful when by mistake you read a keyring that used to be
 // amino-encoded with a binary that depends on cosmos-sdk >=v0.46, because it
@@ -20,34 +127,299 @@ import (
 // properly migrated by listing the keys from kr.dir/amino. Once you are OK
 // with the result, you can simply copy the *.info files from kr.dir/amino
 // into kr.dir, assuming that you used the same password for both keyring.
-func (kr Keyring) MigrateProtoKeysToAmino() error {
-	// new keyring for migrated keys
-	aminoKeyringDir := filepath.Join(kr.dir, "amino")
-	aminoKr, err := New(keyring.FileBackend, aminoKeyringDir, nil)
+// Alternatively, the WithInPlace option does that safely in a single step.
+//
+// The returned report contains one entry per key of kr. Except with the
+// WithInPlace option, a key that fails to migrate doesn't stop the migration
+// of the others, use MigrationReport.Err to check for failures. Once all keys
+// are written, each migrated key is read back from the target keyring and
+// compared to the source key with CompareKeys, a key that differs is reported
+// as failed.
+func (kr Keyring) MigrateProtoKeysToAmino(opts ...MigrateOption) (MigrationReport, error) {
+	return kr.migrate(AminoEncoding, filepath.Join(kr.dir, "amino"), opts)
+}
+
+// MigrateAminoKeysToProto turns all amino encoded keys from kr and migrate
+// them to proto format, in a new keyring located in kr.dir/proto, or in the
+// keyring given by the WithTarget option.
+//
+// This function is the reverse of MigrateProtoKeysToAmino, it is useful when
+// moving a keyring from a binary that depends on cosmos-sdk <v0.46 to a newer
+// one, without relying on the cosmos-sdk automatic migration.
+//
+// Like MigrateProtoKeysToAmino, this migration is not destructive and is done
+// in a separate keyring, the address index entries of the migrated keys are
+// also recorded in kr.dir/proto. Once you are OK with the result, you can
+// simply copy the *.info and *.address files from kr.dir/proto into kr.dir,
+// assuming that you used the same password for both keyring, or use the
+// WithInPlace option.
+func (kr Keyring) MigrateAminoKeysToProto(opts ...MigrateOption) (MigrationReport, error) {
+	return kr.migrate(ProtoEncoding, filepath.Join(kr.dir, "proto"), opts)
+}
+
+func (kr Keyring) migrate(to Encoding, targetDir string, opts []MigrateOption) (MigrationReport, error) {
+	var o migrateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.backup != nil && o.target != nil {
+		return nil, errors.New("WithInPlace and WithTarget options are mutually exclusive")
+	}
+	var (
+		target   = o.target
+		snapshot []keyring.Item
+	)
+	switch {
+	case o.dryRun:
+		target = nil
+	case o.backup != nil:
+		// in-place migration, backup all items before rewriting them.
+		var err error
+		snapshot, err = kr.Snapshot()
+		if err != nil {
+			return nil, fmt.Errorf("snapshot: %w", err)
+		}
+		for _, item := range snapshot {
+			if err := o.backup.k.Set(item); err != nil {
+				return nil, fmt.Errorf("backup %q: %w", item.Key, err)
+			}
+		}
+		target = &kr
+	case target == nil:
+		// new keyring for migrated keys
+		if kr.dir == "" {
+			return nil, errors.New("keyring has no directory for the migrated keys, use the WithTarget or WithInPlace option")
+		}
+		targetKr, err := New(keyring.FileBackend, targetDir, nil, WithCodec(kr.cdc))
+		if err != nil {
+			return nil, err
+		}
+		target = &targetKr
+	}
+	keys, err := kr.k.Keys()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("keyring.Keys: %w", err)
+	}
+	var names []string
+	for _, name := range keys {
+		if strings.HasSuffix(name, infoSuffix) {
+			names = append(names, name)
+		}
+	}
+	var targetNames []string
+	if target != nil {
+		targetNames, err = target.k.Keys()
+		if err != nil {
+			return nil, fmt.Errorf("target keyring.Keys: %w", err)
+		}
+	}
+	var (
+		report   MigrationReport
+		migrated = make(map[string]Key)
+	)
+	for i, name := range names {
+		res, key := kr.migrateItem(name, to, target, o.filter)
+		report = append(report, res)
+		if res.Action == MigrationMigrated {
+			migrated[name] = key
+		}
+		if res.Action == MigrationFailed && snapshot != nil {
+			// in-place migration failed, restore the keyring from the snapshot.
+			for _, name := range names[i+1:] {
+				report = append(report, MigrationResult{Name: name, Action: MigrationNotAttempted})
+			}
+			return report.rollback(), kr.rollback(snapshot, name, res.Err)
+		}
+	}
+	if target == nil {
+		// dry-run, nothing to verify
+		return report, nil
+	}
+	// Verify all keys once every key has been written, this ensures that no
+	// key has been overwritten by another one in the process.
+	source := kr
+	if snapshot != nil {
+		// in-place migration, the source keys are the ones of the snapshot
+		source.k = newMemoryKeyring(snapshot)
+	}
+	for i, res := range report {
+		var err error
+		switch res.Action {
+		case MigrationMigrated:
+			err = checkMigratedKey(migrated[res.Name], to, *target)
+		case MigrationSkipped:
+			err = checkSkippedKey(res.Name, source, *target)
+		}
+		if err != nil {
+			report[i].Action = MigrationFailed
+			report[i].Err = err
+			if snapshot != nil {
+				return report.rollback(), kr.rollback(snapshot, res.Name, err)
+			}
+		}
 	}
-	keys, err := kr.Keys()
+	targetKeys, err := target.k.Keys()
 	if err != nil {
-		return err
+		return report, fmt.Errorf("target keyring.Keys: %w", err)
 	}
-	for _, key := range keys {
-		if key.IsAminoEncoded() {
-			// this is a amino-encoded key  no migration just display
-			fmt.Printf("%q (amino encoded)-> %s\n", key.name, spew.Sdump(key.info))
+	for _, name := range targetKeys {
+		if !strings.HasSuffix(name, infoSuffix) || slices.Contains(names, name) {
 			continue
 		}
-		// this is a proto-encoded key let's migrate it back to amino
-		fmt.Printf("%q (proto encoded)-> %s\n", key.name, spew.Sdump(key.record))
+		if slices.Contains(targetNames, name) {
+			// key of the target keyring before the migration
+			report = append(report, MigrationResult{Name: name, Action: MigrationUnexpected})
+			continue
+		}
+		report = append(report, MigrationResult{Name: name, Action: MigrationUnexpected, Err: errUnexpectedKey})
+		if snapshot != nil {
+			return report.rollback(), kr.rollback(snapshot, name, errUnexpectedKey)
+		}
+	}
+	return report, nil
+}
+
+// migrateItem migrates the key name into target if it is selected by filter
+// and not already in the to encoding. The returned key is only set if the key
+// has been migrated.
+func (kr Keyring) migrateItem(name string, to Encoding, target *Keyring, filter Filter) (MigrationResult, Key) {
+	res := MigrationResult{Name: name, Action: MigrationFailed}
+	selected, err := filter.MatchName(name)
+	if err != nil {
+		res.Err = err
+		return res, Key{}
+	}
+	if !selected {
+		res.Action = MigrationSkipped
+		return res, Key{}
+	}
+	key, err := kr.Get(name)
+	if err != nil {
+		res.Err = err
+		return res, Key{}
+	}
+	res.Encoding = key.Encoding()
+	res.Address, res.Err = key.Address()
+	if res.Err != nil {
+		return res, Key{}
+	}
+	selected, res.Err = filter.Match(key)
+	if res.Err != nil {
+		return res, Key{}
+	}
+	if !selected || key.Encoding() == to {
+		res.Action = MigrationSkipped
+		return res, Key{}
+	}
+	if res.Err = migrateKey(key, to, target); res.Err != nil {
+		return res, Key{}
+	}
+	res.Action = MigrationMigrated
+	return res, key
+}
+
+// rollback marks all the migrated keys of r as rolled back.
+func (r MigrationReport) rollback() MigrationReport {
+	for i := range r {
+		if r[i].Action == MigrationMigrated {
+			r[i].Action = MigrationRolledBack
+		}
+	}
+	return r
+}
+
+// rollback restores kr from snapshot after the failed migration of key name.
+func (kr Keyring) rollback(snapshot []keyring.Item, name string, err error) error {
+	if errRestore := kr.restore(snapshot); errRestore != nil {
+		return fmt.Errorf("migration of %q failed: %w, and restore failed: %v, restore the keyring from the backup", name, err, errRestore)
+	}
+	return fmt.Errorf("migration of %q failed, keyring restored: %w", name, err)
+}
+
+// migrateKey re-encodes key into the to encoding and adds it to target. If
+// target is nil, the key is only re-encoded.
+func migrateKey(key Key, to Encoding, target *Keyring) error {
+	switch to {
+	case AminoEncoding:
 		info, err := key.RecordToInfo()
 		if err != nil {
 			return err
 		}
+		if target == nil {
+			return nil
+		}
 		// Register new amino key_name.info -> amino encoded LegacyInfo
-		if err := aminoKr.AddAmino(key.name, info); err != nil {
+		return target.AddAmino(key.name, info)
+
+	case ProtoEncoding:
+		record, err := key.InfoToRecord()
+		if err != nil {
 			return err
 		}
-		fmt.Printf("%q re-encoded to amino keyring %q\n", key.name, aminoKeyringDir)
+		if target == nil {
+			return nil
+		}
+		// Register new proto key_name.info -> proto encoded Record and
+		// <address>.address -> key_name.info
+		return target.AddProto(key.name, record)
+	}
+	return fmt.Errorf("unhandled encoding %q", to)
+}
+
+// checkSkippedKey ensures that the skipped key name is the same in source and
+// target, if target holds it.
+func checkSkippedKey(name string, source, target Keyring) error {
+	targetItem, err := target.k.Get(name)
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		// skipped keys are not written in a separate target
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read target key: %w", err)
+	}
+	sourceItem, err := source.k.Get(name)
+	if err != nil {
+		return fmt.Errorf("read source key: %w", err)
+	}
+	if bytes.Equal(sourceItem.Data, targetItem.Data) {
+		// untouched key, which may not be decodable if skipped by the filter
+		return nil
+	}
+	sourceKey, err := source.Get(name)
+	if err != nil {
+		return fmt.Errorf("read source key: %w", err)
+	}
+	targetKey, err := target.Get(name)
+	if err != nil {
+		return fmt.Errorf("read target key: %w", err)
+	}
+	return CompareKeys(sourceKey, targetKey)
+}
+
+// checkMigratedKey re-reads key from target and ensures it has the expected
+// encoding, is equivalent to key, and that its address index entry points to
+// it.
+func checkMigratedKey(key Key, to Encoding, target Keyring) error {
+	migrated, err := target.Get(key.name)
+	if err != nil {
+		return fmt.Errorf("read migrated key: %w", err)
+	}
+	if migrated.Encoding() != to {
+		return fmt.Errorf("migrated key has encoding %q, expected %q", migrated.Encoding(), to)
+	}
+	if err := CompareKeys(key, migrated); err != nil {
+		return err
+	}
+	addr, err := key.Address()
+	if err != nil {
+		return err
+	}
+	byAddr, err := target.GetByAddress(addr)
+	if err != nil {
+		return fmt.Errorf("read migrated key by address: %w", err)
+	}
+	if byAddr.name != key.name {
+		return fmt.Errorf("migrated key address points to %q, expected %q", byAddr.name, key.name)
 	}
 	return nil
 }
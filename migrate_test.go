@@ -0,0 +1,390 @@
+package keyring_test
+
+import (
+	"slices"
+	"sort"
+	"testing"
+
+	bkeyring "github.com/99designs/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/multisig"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// newMigrationKeyring returns a keyring with a proto local key and an amino
+// ledger key, along with their public keys.
+func newMigrationKeyring(t *testing.T) (kr keyring.Keyring, localPubKey, ledgerPubKey cryptotypes.PubKey) {
+	t.Helper()
+	require := require.New(t)
+	kr = newKeyring(t)
+	privKey := secp256k1.GenPrivKeyFromSecret([]byte("secret"))
+	localPubKey = privKey.PubKey()
+	ledgerPubKey = secp256k1.GenPrivKeyFromSecret([]byte("ledger")).PubKey()
+	local, err := cosmoskeyring.NewLocalRecord("local", privKey, localPubKey)
+	require.NoError(err)
+	require.NoError(kr.AddProto("local", local))
+	ledger, err := cosmoskeyring.NewLedgerRecord("ledger", ledgerPubKey, hd.NewFundraiserParams(0, 118, 0))
+	require.NoError(err)
+	ledgerInfo, err := keyring.LegacyInfoFromRecord(ledger)
+	require.NoError(err)
+	require.NoError(kr.AddAmino("ledger", ledgerInfo))
+	return kr, localPubKey, ledgerPubKey
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	kr, pubKey, ledgerPubKey := newMigrationKeyring(t)
+
+	tests := []struct {
+		name           string
+		migrate        func(...keyring.MigrateOption) (keyring.MigrationReport, error)
+		expectedReport keyring.MigrationReport
+	}{
+		{
+			name:    "proto to amino",
+			migrate: kr.MigrateProtoKeysToAmino,
+			expectedReport: keyring.MigrationReport{
+				{
+					Name:     "ledger.info",
+					Address:  ledgerPubKey.Address().Bytes(),
+					Encoding: keyring.AminoEncoding,
+					Action:   keyring.MigrationSkipped,
+				},
+				{
+					Name:     "local.info",
+					Address:  pubKey.Address().Bytes(),
+					Encoding: keyring.ProtoEncoding,
+					Action:   keyring.MigrationMigrated,
+				},
+			},
+		},
+		{
+			name:    "amino to proto",
+			migrate: kr.MigrateAminoKeysToProto,
+			expectedReport: keyring.MigrationReport{
+				{
+					Name:     "ledger.info",
+					Address:  ledgerPubKey.Address().Bytes(),
+					Encoding: keyring.AminoEncoding,
+					Action:   keyring.MigrationMigrated,
+				},
+				{
+					Name:     "local.info",
+					Address:  pubKey.Address().Bytes(),
+					Encoding: keyring.ProtoEncoding,
+					Action:   keyring.MigrationSkipped,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+
+			report, err := tt.migrate(keyring.WithDryRun())
+
+			require.NoError(err)
+			sort.Slice(report, func(i, j int) bool { return report[i].Name < report[j].Name })
+			assert.Equal(tt.expectedReport, report)
+			assert.NoError(report.Err())
+		})
+	}
+}
+
+func TestMigrateWithTarget(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	kr, pubKey, ledgerPubKey := newMigrationKeyring(t)
+	target := newKeyring(t)
+
+	report, err := kr.MigrateProtoKeysToAmino(keyring.WithTarget(target))
+
+	require.NoError(err)
+	require.NoError(report.Err())
+	key, err := target.Get("local")
+	require.NoError(err)
+	assert.True(key.IsAminoEncoded())
+	addr, err := key.Address()
+	require.NoError(err)
+	assert.Equal(pubKey.Address().Bytes(), addr.Bytes())
+	key2, err := target.GetByAddress(addr)
+	require.NoError(err)
+	assert.Equal(key, key2)
+	// amino key is skipped and not written in the target keyring
+	_, err = target.Get("ledger")
+	assert.EqualError(err, "The specified item could not be found in the keyring")
+	_, err = target.GetByAddress(sdk.AccAddress(ledgerPubKey.Address()))
+	assert.EqualError(err, "The specified item could not be found in the keyring")
+}
+
+func TestMigrateAminoKeysToProto(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		kr       = newKeyring(t)
+		target   = newKeyring(t)
+		privKey  = secp256k1.GenPrivKeyFromSecret([]byte("secret"))
+		ledgerPK = secp256k1.GenPrivKeyFromSecret([]byte("ledger")).PubKey()
+		multiPK  = multisig.NewLegacyAminoPubKey(1, []cryptotypes.PubKey{privKey.PubKey(), ledgerPK})
+	)
+	local, err := cosmoskeyring.NewLocalRecord("local", privKey, privKey.PubKey())
+	require.NoError(err)
+	ledger, err := cosmoskeyring.NewLedgerRecord("ledger", ledgerPK, hd.NewFundraiserParams(0, 118, 0))
+	require.NoError(err)
+	multi, err := cosmoskeyring.NewMultiRecord("multi", multiPK)
+	require.NoError(err)
+	for _, record := range []*cosmoskeyring.Record{local, ledger, multi} {
+		addRecord(t, kr, record, keyring.AminoEncoding)
+	}
+
+	report, err := kr.MigrateAminoKeysToProto(keyring.WithTarget(target))
+
+	require.NoError(err)
+	require.NoError(report.Err())
+	require.Len(report, 3)
+	for _, res := range report {
+		assert.Equal(keyring.MigrationMigrated, res.Action, res.Name)
+	}
+	for _, name := range []string{"local", "ledger", "multi"} {
+		key, err := kr.Get(name)
+		require.NoError(err)
+		migratedKey, err := target.Get(name)
+		require.NoError(err)
+		assert.Equal(keyring.ProtoEncoding, migratedKey.Encoding(), name)
+		assert.NoError(keyring.CompareKeys(key, migratedKey), name)
+		// The address index is rebuilt in the target keyring
+		addr, err := key.Address()
+		require.NoError(err)
+		keyByAddr, err := target.GetByAddress(addr)
+		require.NoError(err, name)
+		assert.Equal(migratedKey.Name(), keyByAddr.Name())
+		assert.NoError(keyring.CompareKeys(migratedKey, keyByAddr), name)
+	}
+}
+
+func TestMigrateVerification(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	kr, pubKey, ledgerPubKey := newMigrationKeyring(t)
+	target := newKeyring(t)
+	// target holds another version of the skipped ledger key, and a key
+	// unknown to kr.
+	for _, name := range []string{"ledger", "other"} {
+		record, err := cosmoskeyring.NewOfflineRecord(name, secp256k1.GenPrivKeyFromSecret([]byte(name)).PubKey())
+		require.NoError(err)
+		require.NoError(target.AddProto(name, record))
+	}
+
+	report, err := kr.MigrateProtoKeysToAmino(keyring.WithTarget(target))
+
+	require.NoError(err)
+	require.Len(report, 3)
+	assert.Equal(keyring.MigrationResult{
+		Name:     "local.info",
+		Address:  pubKey.Address().Bytes(),
+		Encoding: keyring.ProtoEncoding,
+		Action:   keyring.MigrationMigrated,
+	}, report[1])
+	assert.Equal("ledger.info", report[0].Name)
+	assert.Equal(sdk.AccAddress(ledgerPubKey.Address()), report[0].Address)
+	assert.Equal(keyring.MigrationFailed, report[0].Action)
+	var mismatchErr *keyring.KeyMismatchError
+	require.ErrorAs(report[0].Err, &mismatchErr)
+	assert.Equal("ledger.info", mismatchErr.Name)
+	assert.Contains(mismatchErr.Diffs, "type ledger != offline")
+	// other was in target before the migration, it is reported without error
+	assert.Equal(keyring.MigrationResult{
+		Name:   "other.info",
+		Action: keyring.MigrationUnexpected,
+	}, report[2])
+	assert.EqualError(report.Err(), "ledger.info: "+report[0].Err.Error())
+}
+
+func TestMigrateInPlace(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	kr, _, _ := newMigrationKeyring(t)
+	backup := newKeyring(t)
+
+	report, err := kr.MigrateProtoKeysToAmino(keyring.WithInPlace(backup))
+
+	require.NoError(err)
+	require.NoError(report.Err())
+	for _, name := range []string{"local", "ledger"} {
+		key, err := kr.Get(name)
+		require.NoError(err)
+		assert.True(key.IsAminoEncoded(), name)
+	}
+	// backup contains the original keys
+	key, err := backup.Get("local")
+	require.NoError(err)
+	assert.False(key.IsAminoEncoded())
+	key, err = backup.Get("ledger")
+	require.NoError(err)
+	assert.True(key.IsAminoEncoded())
+}
+
+func TestMigrateInPlaceRollback(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	kr, pubKey, _ := newMigrationKeyring(t)
+	// Add a local key without private key, which can't be migrated. Its name
+	// ensures it's migrated after the valid local key.
+	brokenPubKey, err := codectypes.NewAnyWithValue(secp256k1.GenPrivKeyFromSecret([]byte("broken")).PubKey())
+	require.NoError(err)
+	require.NoError(kr.AddProto("zbroken", &cosmoskeyring.Record{
+		Name:   "zbroken",
+		PubKey: brokenPubKey,
+		Item:   &cosmoskeyring.Record_Local_{Local: &cosmoskeyring.Record_Local{}},
+	}))
+	// Keys after the failed key are not attempted
+	offline, err := cosmoskeyring.NewOfflineRecord("zoffline", secp256k1.GenPrivKeyFromSecret([]byte("offline")).PubKey())
+	require.NoError(err)
+	require.NoError(kr.AddProto("zoffline", offline))
+	backup := newKeyring(t)
+
+	report, err := kr.MigrateProtoKeysToAmino(keyring.WithInPlace(backup))
+
+	require.EqualError(err, `migration of "zbroken.info" failed, keyring restored: private key is not available`)
+	assert.Equal(keyring.MigrationReport{
+		{
+			Name:     "ledger.info",
+			Address:  report[0].Address,
+			Encoding: keyring.AminoEncoding,
+			Action:   keyring.MigrationSkipped,
+		},
+		{
+			Name:     "local.info",
+			Address:  pubKey.Address().Bytes(),
+			Encoding: keyring.ProtoEncoding,
+			Action:   keyring.MigrationRolledBack,
+		},
+		{
+			Name:     "zbroken.info",
+			Address:  brokenPubKey.GetCachedValue().(cryptotypes.PubKey).Address().Bytes(),
+			Encoding: keyring.ProtoEncoding,
+			Action:   keyring.MigrationFailed,
+			Err:      cosmoskeyring.ErrPrivKeyNotAvailable,
+		},
+		{
+			Name:   "zoffline.info",
+			Action: keyring.MigrationNotAttempted,
+		},
+	}, report)
+	// local key has been restored
+	key, err := kr.Get("local")
+	require.NoError(err)
+	assert.False(key.IsAminoEncoded())
+	key, err = kr.Get("zoffline")
+	require.NoError(err)
+	assert.False(key.IsAminoEncoded())
+
+	//-----------------------------------------
+	// Undecodable key
+	snapshot, err := kr.Snapshot()
+	require.NoError(err)
+	snapshot = append(snapshot, bkeyring.Item{Key: "undecodable.info", Data: []byte("garbage")})
+	kr, err = keyring.New(keyring.BackendMemory, "", nil, keyring.WithSnapshot(snapshot))
+	require.NoError(err)
+
+	report, err = kr.MigrateProtoKeysToAmino(keyring.WithInPlace(newKeyring(t)))
+
+	require.ErrorContains(err, `migration of "undecodable.info" failed, keyring restored: cannot decode key undecodable.info`)
+	actions := make(map[string]keyring.MigrationAction)
+	for _, res := range report {
+		actions[res.Name] = res.Action
+	}
+	assert.Equal(map[string]keyring.MigrationAction{
+		"ledger.info":      keyring.MigrationSkipped,
+		"local.info":       keyring.MigrationRolledBack,
+		"undecodable.info": keyring.MigrationFailed,
+		"zbroken.info":     keyring.MigrationNotAttempted,
+		"zoffline.info":    keyring.MigrationNotAttempted,
+	}, actions)
+	key, err = kr.Get("local")
+	require.NoError(err)
+	assert.False(key.IsAminoEncoded())
+}
+
+func TestMigrateWithFilter(t *testing.T) {
+	kr, pubKey, _ := newMigrationKeyring(t)
+	tests := []struct {
+		name           string
+		filter         keyring.Filter
+		expectedAction keyring.MigrationAction
+	}{
+		{
+			name:           "empty filter",
+			expectedAction: keyring.MigrationMigrated,
+		},
+		{
+			name:           "filter by name",
+			filter:         keyring.Filter{Names: []string{"local"}},
+			expectedAction: keyring.MigrationMigrated,
+		},
+		{
+			name:           "filter by other name",
+			filter:         keyring.Filter{Names: []string{"relayer"}},
+			expectedAction: keyring.MigrationSkipped,
+		},
+		{
+			name:           "filter by pattern",
+			filter:         keyring.Filter{Pattern: "lo*"},
+			expectedAction: keyring.MigrationMigrated,
+		},
+		{
+			name:           "filter by other pattern",
+			filter:         keyring.Filter{Pattern: "le*"},
+			expectedAction: keyring.MigrationSkipped,
+		},
+		{
+			name:           "filter by type",
+			filter:         keyring.Filter{Types: []cosmoskeyring.KeyType{cosmoskeyring.TypeLocal}},
+			expectedAction: keyring.MigrationMigrated,
+		},
+		{
+			name:           "filter by other type",
+			filter:         keyring.Filter{Types: []cosmoskeyring.KeyType{cosmoskeyring.TypeLedger}},
+			expectedAction: keyring.MigrationSkipped,
+		},
+		{
+			name:           "filter by address",
+			filter:         keyring.Filter{Addresses: []sdk.AccAddress{sdk.AccAddress(pubKey.Address())}},
+			expectedAction: keyring.MigrationMigrated,
+		},
+		{
+			name:           "filter by other address",
+			filter:         keyring.Filter{Addresses: []sdk.AccAddress{sdk.AccAddress("other")}},
+			expectedAction: keyring.MigrationSkipped,
+		},
+		{
+			name: "filter by name and other type",
+			filter: keyring.Filter{
+				Names: []string{"local"},
+				Types: []cosmoskeyring.KeyType{cosmoskeyring.TypeLedger},
+			},
+			expectedAction: keyring.MigrationSkipped,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			report, err := kr.MigrateProtoKeysToAmino(keyring.WithDryRun(), keyring.WithFilter(tt.filter))
+
+			require.NoError(err)
+			require.NoError(report.Err())
+			idx := slices.IndexFunc(report, func(r keyring.MigrationResult) bool { return r.Name == "local.info" })
+			require.NotEqual(-1, idx)
+			assert.Equal(t, tt.expectedAction, report[idx].Action)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package keyring
+
+import (
+	stded25519 "crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cosmos/go-bip39"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+)
+
+// mnemonicEntropySize is the entropy size of generated mnemonics, which
+// gives 24 words like cosmos-sdk.
+const mnemonicEntropySize = 256
+
+// hardenedIndex is the offset of the hardened child indexes.
+const hardenedIndex = 0x80000000
+
+// Ed25519 is a signature algorithm that derives ed25519 keys from mnemonics
+// following SLIP-0010. SLIP-0010 only defines hardened derivation for ed25519,
+// so all the components of the HD path must be hardened, for instance
+// m/44'/118'/0'/0'/0'. This algorithm isn't supported by default, see
+// WithSupportedAlgorithms.
+var Ed25519 = ed25519Algo{}
+
+type ed25519Algo struct{}
+
+func (ed25519Algo) Name() hd.PubKeyType {
+	return hd.Ed25519Type
+}
+
+func (ed25519Algo) Derive() hd.DeriveFn {
+	return func(mnemonic, bip39Passphrase, hdPath string) ([]byte, error) {
+		seed, err := bip39.NewSeedWithErrorChecking(mnemonic, bip39Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return deriveEd25519(seed, hdPath)
+	}
+}
+
+func (ed25519Algo) Generate() hd.GenerateFn {
+	return func(bz []byte) cryptotypes.PrivKey {
+		return &ed25519.PrivKey{Key: stded25519.NewKeyFromSeed(bz)}
+	}
+}
+
+// deriveEd25519 derives the ed25519 private key at hdPath from seed, as
+// specified by SLIP-0010.
+func deriveEd25519(seed []byte, hdPath string) ([]byte, error) {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+	if hdPath == "m" {
+		return key, nil
+	}
+	if !strings.HasPrefix(hdPath, "m/") {
+		return nil, fmt.Errorf("invalid HD path %q: must start with m/", hdPath)
+	}
+	for _, c := range strings.Split(strings.TrimPrefix(hdPath, "m/"), "/") {
+		index, ok := strings.CutSuffix(c, "'")
+		if !ok {
+			return nil, fmt.Errorf("invalid HD path %q: ed25519 only supports hardened derivation, %q is not hardened", hdPath, c)
+		}
+		i, err := strconv.ParseUint(index, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HD path %q: %w", hdPath, err)
+		}
+		data := append([]byte{0}, key...)
+		data = binary.BigEndian.AppendUint32(data, uint32(i)|hardenedIndex)
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		key, chainCode = sum[:32], sum[32:]
+	}
+	return key, nil
+}
+
+// NewMnemonic generates a new 24 words mnemonic, and creates a local key
+// named name from it, like ImportMnemonic. The mnemonic is returned to the
+// caller and is not stored, so it must be saved by the caller if needed.
+func (k Keyring) NewMnemonic(name, bip39Passphrase, hdPath string, algo cosmoskeyring.SignatureAlgo, encoding Encoding) (Key, string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropySize)
+	if err != nil {
+		return Key{}, "", err
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return Key{}, "", err
+	}
+	key, err := k.ImportMnemonic(name, mnemonic, bip39Passphrase, hdPath, algo, encoding)
+	if err != nil {
+		return Key{}, "", err
+	}
+	return key, mnemonic, nil
+}
+
+// ImportMnemonic derives the private key at hdPath from mnemonic and
+// bip39Passphrase using algo, and stores it as a local key named name with
+// the given encoding, along with its address index entry. algo must be one
+// of the keyring supported algorithms.
+func (k Keyring) ImportMnemonic(name, mnemonic, bip39Passphrase, hdPath string, algo cosmoskeyring.SignatureAlgo, encoding Encoding) (Key, error) {
+	if !k.algos.Contains(algo) {
+		return Key{}, fmt.Errorf("%w: %s", cosmoskeyring.ErrUnsupportedSigningAlgo, algo.Name())
+	}
+	derivedPriv, err := algo.Derive()(mnemonic, bip39Passphrase, hdPath)
+	if err != nil {
+		return Key{}, err
+	}
+	return k.addLocal(name, algo.Generate()(derivedPriv), encoding)
+}
+
+// addLocal stores privKey as a local key named name with the given encoding.
+func (k Keyring) addLocal(name string, privKey cryptotypes.PrivKey, encoding Encoding) (Key, error) {
+	record, err := cosmoskeyring.NewLocalRecord(strings.TrimSuffix(name, infoSuffix), privKey, privKey.PubKey())
+	if err != nil {
+		return Key{}, err
+	}
+	return k.addRecord(name, record, encoding)
+}
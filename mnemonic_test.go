@@ -0,0 +1,78 @@
+package keyring_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+)
+
+func TestMnemonic(t *testing.T) {
+	hdPath := hd.CreateHDPath(118, 0, 0).String()
+	for _, encoding := range []keyring.Encoding{keyring.AminoEncoding, keyring.ProtoEncoding} {
+		t.Run(string(encoding), func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			kr := newKeyring(t)
+
+			//-----------------------------------------
+			// ImportMnemonic()
+			key, err := kr.ImportMnemonic("imported", testMnemonic, "", hdPath, hd.Secp256k1, encoding)
+			require.NoError(err)
+			assert.Equal(encoding, key.Encoding())
+			assert.Equal(cosmoskeyring.TypeLocal, key.Type())
+			assert.Equal("cosmos19rl4cm2hmr8afy4kldpxz3fka4jguq0auqdal4", key.MustBech32Address("cosmos"))
+			addr, err := key.Address()
+			require.NoError(err)
+			key2, err := kr.GetByAddress(addr)
+			require.NoError(err)
+			assert.Equal(key, key2)
+			_, err = kr.ImportMnemonic("imported2", testMnemonic, "", hdPath, hd.Secp256k1, encoding)
+			assert.ErrorIs(err, cosmoskeyring.ErrDuplicatedAddress)
+			_, err = kr.ImportMnemonic("imported", testMnemonic, "passphrase", hdPath, hd.Secp256k1, encoding)
+			assert.ErrorIs(err, cosmoskeyring.ErrKeyAlreadyExists)
+			_, err = kr.ImportMnemonic("ed25519", testMnemonic, "", hdPath, keyring.Ed25519, encoding)
+			assert.ErrorIs(err, cosmoskeyring.ErrUnsupportedSigningAlgo)
+
+			//-----------------------------------------
+			// NewMnemonic()
+			key, mnemonic, err := kr.NewMnemonic("new", "passphrase", hdPath, hd.Secp256k1, encoding)
+			require.NoError(err)
+			assert.Equal(encoding, key.Encoding())
+			// Importing the mnemonic must give the same key
+			kr2 := newKeyring(t)
+			key2, err = kr2.ImportMnemonic("new", mnemonic, "passphrase", hdPath, hd.Secp256k1, encoding)
+			require.NoError(err)
+			require.NoError(keyring.CompareKeys(key, key2))
+		})
+	}
+}
+
+func TestMnemonicEd25519(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	kr := newKeyring(t, keyring.WithSupportedAlgorithms(hd.Secp256k1, keyring.Ed25519))
+
+	key, err := kr.ImportMnemonic("ed25519", testMnemonic, "", "m/44'/118'/0'/0'/0'", keyring.Ed25519, keyring.AminoEncoding)
+
+	require.NoError(err)
+	pubKey, err := key.PubKey()
+	require.NoError(err)
+	assert.Equal("ed25519", pubKey.Type())
+	signature, err := key.Sign([]byte("hello world"))
+	require.NoError(err)
+	assert.True(pubKey.VerifySignature([]byte("hello world"), signature))
+	// Another hardened path derives another key
+	key2, err := kr.ImportMnemonic("ed25519-2", testMnemonic, "", "m/44'/118'/0'/0'/1'", keyring.Ed25519, keyring.AminoEncoding)
+	require.NoError(err)
+	pubKey2, err := key2.PubKey()
+	require.NoError(err)
+	assert.False(pubKey.Equals(pubKey2))
+	// SLIP-0010 doesn't define non-hardened derivation for ed25519
+	_, err = kr.ImportMnemonic("ed25519-3", testMnemonic, "", hd.CreateHDPath(118, 0, 0).String(), keyring.Ed25519, keyring.AminoEncoding)
+	assert.EqualError(err, `invalid HD path "m/44'/118'/0'/0/0": ed25519 only supports hardened derivation, "0" is not hardened`)
+}
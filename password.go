@@ -0,0 +1,192 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/99designs/keyring"
+	"github.com/mtibben/percent"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// keyhashFile is the file where cosmos-sdk stores the bcrypt hash of the
+// password of a file keyring.
+const keyhashFile = "keyhash"
+
+// ChangePassword re-encrypts every item of the file keyring k with the
+// password returned by newPasswordFunc, and returns the keyring opened with
+// the new password. k must not be used afterwards.
+//
+// The items, including the address index entries and the unknown items, are
+// first written in a temporary directory next to the keyring directory, which
+// then replaces the keyring directory. The sub-directories of the keyring
+// directory, like the default migration targets, are kept as is. If present,
+// the cosmos-sdk keyhash file is updated with the new password.
+//
+// If the process is interrupted while the directories are swapped, the
+// keyring directory may be missing, or the backup of the old keyring may be
+// left next to it: New then completes the swap the next time the keyring is
+// opened. Otherwise, the keyring directory is left untouched,
+// and the temporary directory, named after the keyring directory with a dot
+// prefix and a random suffix, can be removed once its sub-directories have
+// been moved back.
+func (k Keyring) ChangePassword(newPasswordFunc func(string) (string, error)) (Keyring, error) {
+	if k.backend != keyring.FileBackend {
+		return Keyring{}, fmt.Errorf("cannot change password of %q keyring, only %q is supported", k.backend, keyring.FileBackend)
+	}
+	password, err := newPasswordFunc(fmt.Sprintf("Enter new password for keyring %q", k.dir))
+	if err != nil {
+		return Keyring{}, err
+	}
+	cfg := keyring.Config{
+		AllowedBackends:  []keyring.BackendType{keyring.FileBackend},
+		FilePasswordFunc: func(_ string) (string, error) { return password, nil },
+	}
+	dir := filepath.Clean(k.dir)
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+"-")
+	if err != nil {
+		return Keyring{}, err
+	}
+	subDirs, err := k.reencrypt(dir, tmpDir, password)
+	if err != nil {
+		return Keyring{}, errors.Join(err, os.RemoveAll(tmpDir))
+	}
+	if err := moveDirs(dir, tmpDir, subDirs); err != nil {
+		return Keyring{}, errors.Join(err, os.RemoveAll(tmpDir))
+	}
+	// Swap directories: the keyring directory is renamed into a backup
+	// directory, so at any time each directory only contains items encrypted
+	// with the same password.
+	backupDir := tmpDir + ".old"
+	if err := os.Rename(dir, backupDir); err != nil {
+		return Keyring{}, errors.Join(err, abortChangePassword(dir, tmpDir, subDirs))
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		if errRestore := os.Rename(backupDir, dir); errRestore != nil {
+			return Keyring{}, fmt.Errorf("%w, and restore failed: %v, the keyring is in %s and %s", err, errRestore, backupDir, tmpDir)
+		}
+		return Keyring{}, errors.Join(err, abortChangePassword(dir, tmpDir, subDirs))
+	}
+	if err := os.RemoveAll(backupDir); err != nil {
+		return Keyring{}, fmt.Errorf("remove %s: %w", backupDir, err)
+	}
+	cfg.FileDir = dir
+	kr := k
+	kr.dir = dir
+	kr.k, err = keyring.Open(cfg)
+	if err != nil {
+		return Keyring{}, err
+	}
+	return kr, nil
+}
+
+// recoverChangePassword completes a ChangePassword interrupted during the
+// swap of the keyring directory dir. If interrupted between the renames, dir
+// is missing, the items encrypted with the old password are in the backup
+// directory, and the complete keyring encrypted with the new password is in
+// the temporary directory, which is renamed into dir. If interrupted after the
+// renames, the backup directory is left alone and is removed.
+func recoverChangePassword(dir string) error {
+	dir = filepath.Clean(dir)
+	backupDirs, err := filepath.Glob(filepath.Join(filepath.Dir(dir), "."+filepath.Base(dir)+"-*.old"))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+		for _, backupDir := range backupDirs {
+			tmpDir := strings.TrimSuffix(backupDir, ".old")
+			if _, err := os.Stat(tmpDir); !errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err := os.RemoveAll(backupDir); err != nil {
+				return fmt.Errorf("remove %s: %w", backupDir, err)
+			}
+		}
+		return nil
+	}
+	switch len(backupDirs) {
+	case 0:
+		return nil
+	case 1:
+	default:
+		return fmt.Errorf("keyring %s is missing and several password change backups were found, restore one of %s manually", dir, strings.Join(backupDirs, ", "))
+	}
+	backupDir := backupDirs[0]
+	tmpDir := strings.TrimSuffix(backupDir, ".old")
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return fmt.Errorf("complete interrupted password change: %w, rename %s into %s to restore the keyring with its old password", err, backupDir, dir)
+	}
+	if err := os.RemoveAll(backupDir); err != nil {
+		return fmt.Errorf("remove %s: %w", backupDir, err)
+	}
+	return nil
+}
+
+// abortChangePassword moves subDirs back from tmpDir to dir, and removes
+// tmpDir.
+func abortChangePassword(dir, tmpDir string, subDirs []string) error {
+	if err := moveDirs(tmpDir, dir, subDirs); err != nil {
+		return fmt.Errorf("%w, sub-directories are left in %s", err, tmpDir)
+	}
+	return os.RemoveAll(tmpDir)
+}
+
+// moveDirs moves the directories names from src to dst. If a move fails, the
+// directories already moved are moved back to src.
+func moveDirs(src, dst string, names []string) error {
+	for i, name := range names {
+		if err := os.Rename(filepath.Join(src, name), filepath.Join(dst, name)); err != nil {
+			return errors.Join(err, moveDirs(dst, src, names[:i]))
+		}
+	}
+	return nil
+}
+
+// reencrypt writes all the items of the keyring located in dir into tmpDir,
+// encrypted with password. It returns the names of the sub-directories of
+// dir, which are not items.
+func (k Keyring) reencrypt(dir, tmpDir, password string) ([]string, error) {
+	tmp, err := keyring.Open(keyring.Config{
+		AllowedBackends:  []keyring.BackendType{keyring.FileBackend},
+		FileDir:          tmpDir,
+		FilePasswordFunc: func(_ string) (string, error) { return password, nil },
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var subDirs []string
+	for _, entry := range entries {
+		switch {
+		case entry.IsDir():
+			subDirs = append(subDirs, entry.Name())
+
+		case entry.Name() == keyhashFile:
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			if err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(filepath.Join(tmpDir, keyhashFile), hash, 0o600); err != nil {
+				return nil, err
+			}
+
+		default:
+			key := percent.Decode(entry.Name())
+			item, err := k.k.Get(key)
+			if err != nil {
+				return nil, fmt.Errorf("keyring.Get %q: %w", key, err)
+			}
+			if err := tmp.Set(item); err != nil {
+				return nil, fmt.Errorf("keyring.Set %q: %w", key, err)
+			}
+		}
+	}
+	return subDirs, nil
+}
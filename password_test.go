@@ -0,0 +1,123 @@
+package keyring_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	bkeyring "github.com/99designs/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+	"golang.org/x/crypto/bcrypt"
+
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+)
+
+func TestChangePassword(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	var (
+		rootDir     = t.TempDir()
+		dir         = filepath.Join(rootDir, "keyring")
+		oldPassword = func(_ string) (string, error) { return "old", nil }
+		newPassword = func(_ string) (string, error) { return "new", nil }
+	)
+	kr, err := keyring.New(bkeyring.FileBackend, dir, oldPassword)
+	require.NoError(err)
+	privKey := secp256k1.GenPrivKeyFromSecret([]byte("secret"))
+	record, err := cosmoskeyring.NewLocalRecord("alice", privKey, privKey.PubKey())
+	require.NoError(err)
+	info, err := keyring.LegacyInfoFromRecord(record)
+	require.NoError(err)
+	require.NoError(kr.AddAmino("alice", info))
+	// Add an unknown item, a keyhash file and a sub-directory
+	raw, err := bkeyring.Open(bkeyring.Config{
+		AllowedBackends:  []bkeyring.BackendType{bkeyring.FileBackend},
+		FileDir:          dir,
+		FilePasswordFunc: oldPassword,
+	})
+	require.NoError(err)
+	require.NoError(raw.Set(bkeyring.Item{Key: "unknown", Data: []byte("data")}))
+	hash, err := bcrypt.GenerateFromPassword([]byte("old"), 2)
+	require.NoError(err)
+	require.NoError(os.WriteFile(filepath.Join(dir, "keyhash"), hash, 0o600))
+	require.NoError(os.Mkdir(filepath.Join(dir, "amino"), 0o700))
+
+	kr, err = kr.ChangePassword(newPassword)
+
+	require.NoError(err)
+	_, err = kr.Get("alice")
+	assert.NoError(err)
+	// Reopen with the new password
+	kr, err = keyring.New(bkeyring.FileBackend, dir, newPassword)
+	require.NoError(err)
+	key, err := kr.Get("alice")
+	require.NoError(err)
+	assert.True(key.IsAminoEncoded())
+	pubKey, err := key.PubKey()
+	require.NoError(err)
+	assert.Equal(privKey.PubKey(), pubKey)
+	raw, err = bkeyring.Open(bkeyring.Config{
+		AllowedBackends:  []bkeyring.BackendType{bkeyring.FileBackend},
+		FileDir:          dir,
+		FilePasswordFunc: newPassword,
+	})
+	require.NoError(err)
+	item, err := raw.Get("unknown")
+	require.NoError(err)
+	assert.Equal([]byte("data"), item.Data)
+	hash, err = os.ReadFile(filepath.Join(dir, "keyhash"))
+	require.NoError(err)
+	assert.NoError(bcrypt.CompareHashAndPassword(hash, []byte("new")))
+	assert.DirExists(filepath.Join(dir, "amino"))
+	// The old password no longer works
+	kr, err = keyring.New(bkeyring.FileBackend, dir, oldPassword)
+	require.NoError(err)
+	_, err = kr.Get("alice")
+	assert.Error(err)
+	// No temporary directory left behind
+	entries, err := os.ReadDir(rootDir)
+	require.NoError(err)
+	assert.Len(entries, 1)
+
+	//-----------------------------------------
+	// Interrupted directories swap
+	// Simulate a crash between the renames: the keyring with the new password
+	// is in the temporary directory, and the old one in the backup directory.
+	tmpDir := filepath.Join(rootDir, ".keyring-123")
+	require.NoError(os.Rename(dir, tmpDir))
+	require.NoError(os.Mkdir(tmpDir+".old", 0o700))
+
+	kr, err = keyring.New(bkeyring.FileBackend, dir, newPassword)
+
+	require.NoError(err)
+	_, err = kr.Get("alice")
+	assert.NoError(err)
+	assert.DirExists(filepath.Join(dir, "amino"))
+	entries, err = os.ReadDir(rootDir)
+	require.NoError(err)
+	assert.Len(entries, 1)
+
+	//-----------------------------------------
+	// Interrupted backup removal
+	// Simulate a crash after the renames: only the backup directory is left.
+	require.NoError(os.Mkdir(tmpDir+".old", 0o700))
+
+	kr, err = keyring.New(bkeyring.FileBackend, dir, newPassword)
+
+	require.NoError(err)
+	_, err = kr.Get("alice")
+	assert.NoError(err)
+	assert.NoDirExists(tmpDir + ".old")
+	entries, err = os.ReadDir(rootDir)
+	require.NoError(err)
+	assert.Len(entries, 1)
+
+	// Only file keyrings are supported
+	kr, err = keyring.New(keyring.BackendMemory, "", nil)
+	require.NoError(err)
+	_, err = kr.ChangePassword(newPassword)
+	assert.EqualError(err, `cannot change password of "memory" keyring, only "file" is supported`)
+}
@@ -0,0 +1,313 @@
+package keyring
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/keyring"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+// SDKKeyring wraps a Keyring to implement the cosmos-sdk keyring interface,
+// so it can be used where a cosmoskeyring.Keyring is expected, like in
+// client.Context.
+//
+// Unlike the cosmos-sdk keyring, SDKKeyring never migrates the keys: amino
+// keys are converted to Record on the fly when read, and stay amino-encoded
+// in the keyring. New keys are stored using the encoding given to
+// NewSDKKeyring.
+type SDKKeyring struct {
+	kr       Keyring
+	encoding Encoding
+}
+
+var _ cosmoskeyring.Keyring = SDKKeyring{}
+
+// NewSDKKeyring returns a SDKKeyring that wraps kr, and stores new keys using
+// encoding.
+func NewSDKKeyring(kr Keyring, encoding Encoding) SDKKeyring {
+	return SDKKeyring{kr: kr, encoding: encoding}
+}
+
+// Backend implements cosmoskeyring.Keyring.
+func (s SDKKeyring) Backend() string {
+	return string(s.kr.backend)
+}
+
+// List implements cosmoskeyring.Keyring.
+func (s SDKKeyring) List() ([]*cosmoskeyring.Record, error) {
+	keys, err := s.kr.Keys()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*cosmoskeyring.Record, len(keys))
+	for i, key := range keys {
+		records[i], err = key.AsRecord()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key.name, err)
+		}
+	}
+	return records, nil
+}
+
+// SupportedAlgorithms implements cosmoskeyring.Keyring.
+func (s SDKKeyring) SupportedAlgorithms() (cosmoskeyring.SigningAlgoList, cosmoskeyring.SigningAlgoList) {
+	return s.kr.algos, cosmoskeyring.SigningAlgoList{hd.Secp256k1}
+}
+
+// Key implements cosmoskeyring.Keyring.
+func (s SDKKeyring) Key(uid string) (*cosmoskeyring.Record, error) {
+	key, err := s.get(uid)
+	if err != nil {
+		return nil, err
+	}
+	return key.AsRecord()
+}
+
+// KeyByAddress implements cosmoskeyring.Keyring.
+func (s SDKKeyring) KeyByAddress(address sdk.Address) (*cosmoskeyring.Record, error) {
+	key, err := s.getByAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return key.AsRecord()
+}
+
+// Delete implements cosmoskeyring.Keyring.
+func (s SDKKeyring) Delete(uid string) error {
+	if _, err := s.get(uid); err != nil {
+		return err
+	}
+	return s.kr.Remove(uid)
+}
+
+// DeleteByAddress implements cosmoskeyring.Keyring.
+func (s SDKKeyring) DeleteByAddress(address sdk.Address) error {
+	key, err := s.getByAddress(address)
+	if err != nil {
+		return err
+	}
+	return s.kr.Remove(key.name)
+}
+
+// Rename implements cosmoskeyring.Keyring. The key keeps its encoding.
+func (s SDKKeyring) Rename(from, to string) error {
+	if _, err := s.get(from); err != nil {
+		return err
+	}
+	return s.kr.Rename(from, to)
+}
+
+// NewMnemonic implements cosmoskeyring.Keyring.
+func (s SDKKeyring) NewMnemonic(uid string, language cosmoskeyring.Language, hdPath, bip39Passphrase string, algo cosmoskeyring.SignatureAlgo) (*cosmoskeyring.Record, string, error) {
+	if language != cosmoskeyring.English {
+		return nil, "", cosmoskeyring.ErrUnsupportedLanguage
+	}
+	key, mnemonic, err := s.kr.NewMnemonic(uid, bip39Passphrase, hdPath, algo, s.encoding)
+	if err != nil {
+		return nil, "", err
+	}
+	record, err := key.AsRecord()
+	if err != nil {
+		return nil, "", err
+	}
+	return record, mnemonic, nil
+}
+
+// NewAccount implements cosmoskeyring.Keyring.
+func (s SDKKeyring) NewAccount(uid, mnemonic, bip39Passphrase, hdPath string, algo cosmoskeyring.SignatureAlgo) (*cosmoskeyring.Record, error) {
+	key, err := s.kr.ImportMnemonic(uid, mnemonic, bip39Passphrase, hdPath, algo, s.encoding)
+	if err != nil {
+		return nil, err
+	}
+	return key.AsRecord()
+}
+
+// SaveLedgerKey implements cosmoskeyring.Keyring. Like cosmos-sdk, the address
+// is shown on the device with the bech32 prefix hrp, and must be confirmed by
+// the user.
+func (s SDKKeyring) SaveLedgerKey(uid string, algo cosmoskeyring.SignatureAlgo, hrp string, coinType, account, index uint32) (*cosmoskeyring.Record, error) {
+	if algo.Name() != hd.Secp256k1Type {
+		return nil, fmt.Errorf("%w: signature algo %s is not supported by ledger", cosmoskeyring.ErrUnsupportedSigningAlgo, algo.Name())
+	}
+	key, err := s.kr.AddLedger(uid, hd.NewFundraiserParams(account, coinType, index), s.encoding, WithAddressConfirmation(hrp))
+	if err != nil {
+		return nil, err
+	}
+	return key.AsRecord()
+}
+
+// SaveOfflineKey implements cosmoskeyring.Keyring.
+func (s SDKKeyring) SaveOfflineKey(uid string, pubkey cryptotypes.PubKey) (*cosmoskeyring.Record, error) {
+	record, err := cosmoskeyring.NewOfflineRecord(uid, pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveRecord(uid, record)
+}
+
+// SaveMultisig implements cosmoskeyring.Keyring.
+func (s SDKKeyring) SaveMultisig(uid string, pubkey cryptotypes.PubKey) (*cosmoskeyring.Record, error) {
+	record, err := cosmoskeyring.NewMultiRecord(uid, pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveRecord(uid, record)
+}
+
+// Sign implements cosmoskeyring.Keyring.
+func (s SDKKeyring) Sign(uid string, msg []byte, signMode signing.SignMode) ([]byte, cryptotypes.PubKey, error) {
+	key, err := s.get(uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.sign(key, msg, signMode)
+}
+
+// SignByAddress implements cosmoskeyring.Keyring.
+func (s SDKKeyring) SignByAddress(address sdk.Address, msg []byte, signMode signing.SignMode) ([]byte, cryptotypes.PubKey, error) {
+	key, err := s.getByAddress(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.sign(key, msg, signMode)
+}
+
+func (s SDKKeyring) sign(key Key, msg []byte, signMode signing.SignMode) ([]byte, cryptotypes.PubKey, error) {
+	pubKey, err := key.PubKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	switch key.Type() {
+	case cosmoskeyring.TypeOffline, cosmoskeyring.TypeMulti:
+		return nil, pubKey, cosmoskeyring.ErrOfflineSign
+	}
+	signature, err := key.Sign(msg, WithSignMode(signMode))
+	if err != nil {
+		return nil, nil, err
+	}
+	return signature, pubKey, nil
+}
+
+// ImportPrivKey implements cosmoskeyring.Keyring.
+func (s SDKKeyring) ImportPrivKey(uid, armor, passphrase string) error {
+	if _, err := s.get(uid); err == nil {
+		return fmt.Errorf("%w: %s", cosmoskeyring.ErrOverwriteKey, uid)
+	}
+	_, err := s.kr.ImportPrivKeyArmor(uid, armor, passphrase, s.encoding)
+	return err
+}
+
+// ImportPrivKeyHex implements cosmoskeyring.Keyring.
+func (s SDKKeyring) ImportPrivKeyHex(uid, privKey, algoStr string) error {
+	if _, err := s.get(uid); err == nil {
+		return fmt.Errorf("%w: %s", cosmoskeyring.ErrOverwriteKey, uid)
+	}
+	decodedPriv, err := hex.DecodeString(strings.TrimPrefix(privKey, "0x"))
+	if err != nil {
+		return err
+	}
+	algo, err := cosmoskeyring.NewSigningAlgoFromString(algoStr, s.kr.algos)
+	if err != nil {
+		return err
+	}
+	_, err = s.saveLocalKey(uid, algo.Generate()(decodedPriv))
+	return err
+}
+
+// ImportPubKey implements cosmoskeyring.Keyring.
+func (s SDKKeyring) ImportPubKey(uid, armor string) error {
+	if _, err := s.get(uid); err == nil {
+		return fmt.Errorf("%w: %s", cosmoskeyring.ErrOverwriteKey, uid)
+	}
+	_, err := s.kr.ImportPubKeyArmor(uid, armor, s.encoding)
+	return err
+}
+
+// ExportPubKeyArmor implements cosmoskeyring.Keyring.
+func (s SDKKeyring) ExportPubKeyArmor(uid string) (string, error) {
+	key, err := s.get(uid)
+	if err != nil {
+		return "", err
+	}
+	return key.ExportPubKeyArmor()
+}
+
+// ExportPubKeyArmorByAddress implements cosmoskeyring.Keyring.
+func (s SDKKeyring) ExportPubKeyArmorByAddress(address sdk.Address) (string, error) {
+	key, err := s.getByAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return key.ExportPubKeyArmor()
+}
+
+// ExportPrivKeyArmor implements cosmoskeyring.Keyring.
+func (s SDKKeyring) ExportPrivKeyArmor(uid, encryptPassphrase string) (string, error) {
+	key, err := s.get(uid)
+	if err != nil {
+		return "", err
+	}
+	return key.exportPrivKeyArmor(encryptPassphrase)
+}
+
+// ExportPrivKeyArmorByAddress implements cosmoskeyring.Keyring.
+func (s SDKKeyring) ExportPrivKeyArmorByAddress(address sdk.Address, encryptPassphrase string) (string, error) {
+	key, err := s.getByAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return key.exportPrivKeyArmor(encryptPassphrase)
+}
+
+// MigrateAll implements cosmoskeyring.Keyring. Unlike cosmos-sdk, no
+// migration happens, the keys are only returned as Records.
+func (s SDKKeyring) MigrateAll() ([]*cosmoskeyring.Record, error) {
+	return s.List()
+}
+
+func (s SDKKeyring) saveLocalKey(uid string, privKey cryptotypes.PrivKey) (*cosmoskeyring.Record, error) {
+	key, err := s.kr.addLocal(uid, privKey, s.encoding)
+	if err != nil {
+		return nil, err
+	}
+	return key.AsRecord()
+}
+
+func (s SDKKeyring) saveRecord(uid string, record *cosmoskeyring.Record) (*cosmoskeyring.Record, error) {
+	key, err := s.kr.addRecord(uid, record, s.encoding)
+	if err != nil {
+		return nil, err
+	}
+	return key.AsRecord()
+}
+
+// get returns the key named uid, like cosmos-sdk it returns an error
+// wrapping sdkerrors.ErrKeyNotFound if the key doesn't exist.
+func (s SDKKeyring) get(uid string) (Key, error) {
+	key, err := s.kr.Get(uid)
+	return key, wrapKeyNotFound(err, fmt.Sprintf("%s.info", strings.TrimSuffix(uid, infoSuffix)))
+}
+
+// getByAddress returns the key with the given address, like cosmos-sdk it
+// returns an error wrapping sdkerrors.ErrKeyNotFound if the key doesn't
+// exist.
+func (s SDKKeyring) getByAddress(address sdk.Address) (Key, error) {
+	key, err := s.kr.GetByAddress(address)
+	return key, wrapKeyNotFound(err, fmt.Sprintf("key with address %s not found", address))
+}
+
+func wrapKeyNotFound(err error, msg string) error {
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		return fmt.Errorf("%s: %w", msg, sdkerrors.ErrKeyNotFound)
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package keyring_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+func TestSDKKeyring(t *testing.T) {
+	for _, encoding := range []keyring.Encoding{keyring.AminoEncoding, keyring.ProtoEncoding} {
+		t.Run(string(encoding), func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+			kr, _ := newLedgerKeyring(t)
+			sdkKr := keyring.NewSDKKeyring(kr, encoding)
+			assert.Equal("file", sdkKr.Backend())
+
+			//-----------------------------------------
+			// NewAccount() & SaveLedgerKey()
+			record, err := sdkKr.NewAccount("local", testMnemonic, "", hd.CreateHDPath(118, 0, 0).String(), hd.Secp256k1)
+			require.NoError(err)
+			assert.Equal("local", record.Name)
+			_, err = sdkKr.SaveLedgerKey("ledger", hd.Secp256k1, "cosmos", 118, 0, 0)
+			require.NoError(err)
+			// keys are stored with the expected encoding
+			for _, name := range []string{"local", "ledger"} {
+				key, err := kr.Get(name)
+				require.NoError(err)
+				assert.Equal(encoding, key.Encoding())
+			}
+
+			//-----------------------------------------
+			// List() & Key() & KeyByAddress()
+			records, err := sdkKr.List()
+			require.NoError(err)
+			require.Len(records, 2)
+			record, err = sdkKr.Key("local")
+			require.NoError(err)
+			addr, err := record.GetAddress()
+			require.NoError(err)
+			record2, err := sdkKr.KeyByAddress(addr)
+			require.NoError(err)
+			assert.Equal(record.String(), record2.String())
+			_, err = sdkKr.Key("unknown")
+			assert.ErrorIs(err, sdkerrors.ErrKeyNotFound)
+
+			//-----------------------------------------
+			// Sign()
+			msg := []byte("hello world")
+			for _, name := range []string{"local", "ledger"} {
+				signature, pubKey, err := sdkKr.Sign(name, msg, signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON)
+				require.NoError(err)
+				assert.True(pubKey.VerifySignature(msg, signature))
+			}
+
+			//-----------------------------------------
+			// ExportPrivKeyArmor() & ImportPrivKey()
+			armor, err := sdkKr.ExportPrivKeyArmor("local", "passphrase")
+			require.NoError(err)
+			err = sdkKr.ImportPrivKey("local", armor, "passphrase")
+			require.ErrorIs(err, cosmoskeyring.ErrOverwriteKey)
+			require.NoError(sdkKr.Delete("local"))
+			require.NoError(sdkKr.ImportPrivKey("local", armor, "passphrase"))
+			record2, err = sdkKr.Key("local")
+			require.NoError(err)
+			assert.Equal(record.String(), record2.String())
+
+			//-----------------------------------------
+			// ExportPubKeyArmor() & ImportPubKey()
+			armor, err = sdkKr.ExportPubKeyArmor("ledger")
+			require.NoError(err)
+			require.NoError(sdkKr.Delete("ledger"))
+			require.NoError(sdkKr.ImportPubKey("offline", armor))
+			record, err = sdkKr.Key("offline")
+			require.NoError(err)
+			assert.Equal(cosmoskeyring.TypeOffline, record.GetType())
+			_, _, err = sdkKr.Sign("offline", msg, signing.SignMode_SIGN_MODE_DIRECT)
+			assert.ErrorIs(err, cosmoskeyring.ErrOfflineSign)
+
+			//-----------------------------------------
+			// Rename()
+			require.NoError(sdkKr.Rename("local", "renamed"))
+			_, err = sdkKr.Key("local")
+			assert.ErrorIs(err, sdkerrors.ErrKeyNotFound)
+			record2, err = sdkKr.KeyByAddress(addr)
+			require.NoError(err)
+			assert.Equal("renamed", record2.Name)
+			key, err := kr.Get("renamed")
+			require.NoError(err)
+			assert.Equal(encoding, key.Encoding())
+
+			//-----------------------------------------
+			// DeleteByAddress()
+			require.NoError(sdkKr.DeleteByAddress(addr))
+			_, err = sdkKr.Key("renamed")
+			assert.ErrorIs(err, sdkerrors.ErrKeyNotFound)
+			_, err = sdkKr.KeyByAddress(addr)
+			assert.ErrorIs(err, sdkerrors.ErrKeyNotFound)
+			err = sdkKr.DeleteByAddress(addr)
+			assert.ErrorIs(err, sdkerrors.ErrKeyNotFound)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	txv1beta1 "cosmossdk.io/api/cosmos/tx/v1beta1"
+	txsigning "cosmossdk.io/x/tx/signing"
+	"cosmossdk.io/x/tx/signing/aminojson"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+// ErrUnsupportedTxSignMode is returned by SignTx when the sign mode isn't one
+// of the modes it can build the sign bytes for.
+var ErrUnsupportedTxSignMode = errors.New("unsupported sign mode, expected DIRECT or LEGACY_AMINO_JSON")
+
+// SignTx signs the transaction made of body and authInfo with the key, for
+// the account accountNumber and sequence on chain chainID. It returns the
+// signed transaction, which auth info holds the signer info of the key, in
+// place of any signer info of authInfo.
+//
+// The sign mode is SIGN_MODE_DIRECT by default and can be changed to
+// SIGN_MODE_LEGACY_AMINO_JSON with the WithSignMode option. Ledger keys always
+// use SIGN_MODE_LEGACY_AMINO_JSON, which is the only mode supported by all the
+// Cosmos app versions. In this mode, the messages of body must be registered
+// in the gogoproto registry, which is the case once their go package is
+// imported.
+func (k Key) SignTx(body *tx.TxBody, authInfo *tx.AuthInfo, chainID string, accountNumber, sequence uint64, opts ...SignOption) (*tx.Tx, error) {
+	if body == nil || authInfo == nil {
+		return nil, errors.New("transaction body and auth info are required")
+	}
+	o := signOptions{signMode: signing.SignMode_SIGN_MODE_DIRECT}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	switch k.Type() {
+	case cosmoskeyring.TypeLedger:
+		o.signMode = signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON
+	case cosmoskeyring.TypeOffline, cosmoskeyring.TypeMulti:
+		return nil, cosmoskeyring.ErrOfflineSign
+	}
+	if o.signMode != signing.SignMode_SIGN_MODE_DIRECT &&
+		o.signMode != signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON {
+		return nil, fmt.Errorf("%w, got %s", ErrUnsupportedTxSignMode, o.signMode)
+	}
+	pubKey, err := k.PubKey()
+	if err != nil {
+		return nil, err
+	}
+	pubKeyAny, err := codectypes.NewAnyWithValue(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	signedAuthInfo := *authInfo
+	signedAuthInfo.SignerInfos = []*tx.SignerInfo{{
+		PublicKey: pubKeyAny,
+		ModeInfo: &tx.ModeInfo{
+			Sum: &tx.ModeInfo_Single_{Single: &tx.ModeInfo_Single{Mode: o.signMode}},
+		},
+		Sequence: sequence,
+	}}
+	bodyBytes, err := k.codecs().Proto.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal body: %w", err)
+	}
+	authInfoBytes, err := k.codecs().Proto.Marshal(&signedAuthInfo)
+	if err != nil {
+		return nil, fmt.Errorf("marshal auth info: %w", err)
+	}
+	signBytes, err := k.txSignBytes(o.signMode, bodyBytes, authInfoBytes, chainID, accountNumber, sequence)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := k.Sign(signBytes, WithSignMode(o.signMode))
+	if err != nil {
+		return nil, err
+	}
+	return &tx.Tx{
+		Body:       body,
+		AuthInfo:   &signedAuthInfo,
+		Signatures: [][]byte{sig},
+	}, nil
+}
+
+// txSignBytes returns the bytes to sign with signMode for the transaction
+// made of bodyBytes and authInfoBytes.
+func (k Key) txSignBytes(signMode signing.SignMode, bodyBytes, authInfoBytes []byte, chainID string, accountNumber, sequence uint64) ([]byte, error) {
+	switch signMode {
+	case signing.SignMode_SIGN_MODE_DIRECT:
+		return k.codecs().Proto.Marshal(&tx.SignDoc{
+			BodyBytes:     bodyBytes,
+			AuthInfoBytes: authInfoBytes,
+			ChainId:       chainID,
+			AccountNumber: accountNumber,
+		})
+
+	case signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON:
+		var (
+			body     txv1beta1.TxBody
+			authInfo txv1beta1.AuthInfo
+		)
+		if err := proto.Unmarshal(bodyBytes, &body); err != nil {
+			return nil, fmt.Errorf("unmarshal body: %w", err)
+		}
+		if err := proto.Unmarshal(authInfoBytes, &authInfo); err != nil {
+			return nil, fmt.Errorf("unmarshal auth info: %w", err)
+		}
+		addr, err := k.Address()
+		if err != nil {
+			return nil, err
+		}
+		handler := aminojson.NewSignModeHandler(aminojson.SignModeHandlerOptions{})
+		return handler.GetSignBytes(context.Background(), txsigning.SignerData{
+			Address:       addr.String(),
+			ChainID:       chainID,
+			AccountNumber: accountNumber,
+			Sequence:      sequence,
+		}, txsigning.TxData{
+			Body:          &body,
+			AuthInfo:      &authInfo,
+			BodyBytes:     bodyBytes,
+			AuthInfoBytes: authInfoBytes,
+		})
+	}
+	return nil, fmt.Errorf("%w, got %s", ErrUnsupportedTxSignMode, signMode)
+}
@@ -0,0 +1,132 @@
+package keyring_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+	"github.com/tbruyelle/keyring-compat/codec"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+func TestSignTx(t *testing.T) {
+	kr, _ := newLedgerKeyring(t)
+	privKey := secp256k1.GenPrivKeyFromSecret([]byte("secret"))
+	record, err := cosmoskeyring.NewLocalRecord("local", privKey, privKey.PubKey())
+	require.NoError(t, err)
+	require.NoError(t, kr.AddProto("local", record))
+	local, err := kr.Get("local")
+	require.NoError(t, err)
+	ledger, err := kr.AddLedger("ledger", hd.NewFundraiserParams(0, 118, 0), keyring.AminoEncoding)
+	require.NoError(t, err)
+	from, err := local.Address()
+	require.NoError(t, err)
+	msg, err := codectypes.NewAnyWithValue(&banktypes.MsgSend{
+		FromAddress: from.String(),
+		ToAddress:   "cosmos1m3h30wlvsf8llruxtpukdvsy0km2kum8g38c8q",
+		Amount:      sdk.NewCoins(sdk.NewInt64Coin("uatom", 1)),
+	})
+	require.NoError(t, err)
+	body := &tx.TxBody{Messages: []*codectypes.Any{msg}, Memo: "memo"}
+	authInfo := &tx.AuthInfo{
+		Fee: &tx.Fee{Amount: sdk.NewCoins(sdk.NewInt64Coin("uatom", 10)), GasLimit: 200000},
+	}
+	aminoSignBytes := []byte(`{"account_number":"1","chain_id":"chain","fee":{"amount":[{"amount":"10","denom":"uatom"}],"gas":"200000"},"memo":"memo","msgs":[{"type":"cosmos-sdk/MsgSend","value":{"amount":[{"amount":"1","denom":"uatom"}],"from_address":"` + from.String() + `","to_address":"cosmos1m3h30wlvsf8llruxtpukdvsy0km2kum8g38c8q"}}],"sequence":"2"}`)
+	tests := []struct {
+		name             string
+		key              keyring.Key
+		opts             []keyring.SignOption
+		expectedSignMode signing.SignMode
+		expectedError    string
+	}{
+		{
+			name:             "local direct",
+			key:              local,
+			expectedSignMode: signing.SignMode_SIGN_MODE_DIRECT,
+		},
+		{
+			name:             "local amino-json",
+			key:              local,
+			opts:             []keyring.SignOption{keyring.WithSignMode(signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON)},
+			expectedSignMode: signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON,
+		},
+		{
+			name:             "ledger forced to amino-json",
+			key:              ledger,
+			opts:             []keyring.SignOption{keyring.WithSignMode(signing.SignMode_SIGN_MODE_DIRECT)},
+			expectedSignMode: signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON,
+		},
+		{
+			name:          "unsupported sign mode",
+			key:           local,
+			opts:          []keyring.SignOption{keyring.WithSignMode(signing.SignMode_SIGN_MODE_TEXTUAL)},
+			expectedError: "unsupported sign mode, expected DIRECT or LEGACY_AMINO_JSON, got SIGN_MODE_TEXTUAL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			assert := assert.New(t)
+
+			signedTx, err := tt.key.SignTx(body, authInfo, "chain", 1, 2, tt.opts...)
+
+			if tt.expectedError != "" {
+				require.EqualError(err, tt.expectedError)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(body, signedTx.Body)
+			assert.Empty(authInfo.SignerInfos, "authInfo must not be modified")
+			require.Len(signedTx.AuthInfo.SignerInfos, 1)
+			signerInfo := signedTx.AuthInfo.SignerInfos[0]
+			assert.EqualValues(2, signerInfo.Sequence)
+			assert.Equal(tt.expectedSignMode, signerInfo.ModeInfo.GetSingle().Mode)
+			pubKey, err := tt.key.PubKey()
+			require.NoError(err)
+			assert.Equal(pubKey, signerInfo.PublicKey.GetCachedValue())
+			require.Len(signedTx.Signatures, 1)
+			signBytes := aminoSignBytes
+			if tt.expectedSignMode == signing.SignMode_SIGN_MODE_DIRECT {
+				bodyBytes, err := codec.Proto.Marshal(signedTx.Body)
+				require.NoError(err)
+				authInfoBytes, err := codec.Proto.Marshal(signedTx.AuthInfo)
+				require.NoError(err)
+				signBytes, err = codec.Proto.Marshal(&tx.SignDoc{
+					BodyBytes:     bodyBytes,
+					AuthInfoBytes: authInfoBytes,
+					ChainId:       "chain",
+					AccountNumber: 1,
+				})
+				require.NoError(err)
+			}
+			assert.NoError(tt.key.Verify(signBytes, signedTx.Signatures[0]))
+		})
+	}
+
+	t.Run("offline key", func(t *testing.T) {
+		record, err := cosmoskeyring.NewOfflineRecord("offline", secp256k1.GenPrivKey().PubKey())
+		require.NoError(t, err)
+		require.NoError(t, kr.AddProto("offline", record))
+		offline, err := kr.Get("offline")
+		require.NoError(t, err)
+
+		_, err = offline.SignTx(body, authInfo, "chain", 1, 2)
+
+		assert.ErrorIs(t, err, cosmoskeyring.ErrOfflineSign)
+	})
+
+	t.Run("nil auth info", func(t *testing.T) {
+		_, err := local.SignTx(body, nil, "chain", 1, 2)
+
+		assert.EqualError(t, err, "transaction body and auth info are required")
+	})
+}
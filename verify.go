@@ -0,0 +1,169 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/tbruyelle/keyring-compat/codec"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/crypto/types/multisig"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+// ErrInvalidSignature is returned when a signature doesn't match the message
+// and the public key.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Verify returns nil if sig is a valid signature of msg by the key, whatever
+// its type and encoding.
+//
+// For secp256k1 keys, sig must be in the 64 bytes R || S form with a low S, as
+// returned by Sign. For multisig keys, sig is the amino encoded multisignature
+// found in legacy StdTx signatures, which is verified like VerifyMultisig does.
+func (k Key) Verify(msg, sig []byte) error {
+	pubKey, err := k.PubKey()
+	if err != nil {
+		return err
+	}
+	if multiPubKey, ok := pubKey.(multisig.PubKey); ok {
+		multiSig, err := decodeAminoMultisignature(k.codecs(), multiPubKey, sig)
+		if err != nil {
+			return err
+		}
+		return verifyMultisig(multiPubKey, msg, multiSig)
+	}
+	return verifySignature(pubKey, msg, sig)
+}
+
+// VerifyMultisig returns nil if sig holds at least threshold valid signatures
+// of msg by the members of the multisig key. Nested multisig members are
+// verified recursively.
+func (k Key) VerifyMultisig(msg []byte, sig *signing.MultiSignatureData) error {
+	pubKey, err := k.PubKey()
+	if err != nil {
+		return err
+	}
+	multiPubKey, ok := pubKey.(multisig.PubKey)
+	if !ok {
+		return fmt.Errorf("key %s is not a multisig key", k.Name())
+	}
+	return verifyMultisig(multiPubKey, msg, sig)
+}
+
+func verifyMultisig(pubKey multisig.PubKey, msg []byte, sig *signing.MultiSignatureData) error {
+	if sig == nil || sig.BitArray == nil {
+		return fmt.Errorf("%w: multisignature has no bit array", ErrInvalidSignature)
+	}
+	if n := sig.BitArray.NumTrueBitsBefore(sig.BitArray.Count()); n != len(sig.Signatures) {
+		return fmt.Errorf("%w: multisignature has %d bits set for %d signatures", ErrInvalidSignature, n, len(sig.Signatures))
+	}
+	if err := checkLowS(pubKey, sig); err != nil {
+		return err
+	}
+	err := pubKey.VerifyMultisignature(func(signing.SignMode) ([]byte, error) {
+		return msg, nil
+	}, sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// checkLowS ensures that the secp256k1 signatures of the multisig sig are in
+// the low S form, before VerifyMultisignature silently rejects them.
+func checkLowS(pubKey multisig.PubKey, sig *signing.MultiSignatureData) error {
+	pubKeys := pubKey.GetPubKeys()
+	sigIndex := 0
+	for i := 0; i < sig.BitArray.Count() && i < len(pubKeys); i++ {
+		if !sig.BitArray.GetIndex(i) || sigIndex >= len(sig.Signatures) {
+			continue
+		}
+		switch data := sig.Signatures[sigIndex].(type) {
+		case *signing.SingleSignatureData:
+			if err := checkSecp256k1LowS(pubKeys[i], data.Signature); err != nil {
+				return fmt.Errorf("signature at index %d: %w", i, err)
+			}
+		case *signing.MultiSignatureData:
+			if nested, ok := pubKeys[i].(multisig.PubKey); ok {
+				if err := checkLowS(nested, data); err != nil {
+					return err
+				}
+			}
+		}
+		sigIndex++
+	}
+	return nil
+}
+
+func verifySignature(pubKey cryptotypes.PubKey, msg, sig []byte) error {
+	if _, ok := pubKey.(multisig.PubKey); ok {
+		// multisig.PubKey.VerifySignature panics
+		return fmt.Errorf("%w: multisig public key requires a multisignature", ErrInvalidSignature)
+	}
+	if err := checkSecp256k1LowS(pubKey, sig); err != nil {
+		return err
+	}
+	if !pubKey.VerifySignature(msg, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// checkSecp256k1LowS returns an error if pubKey is a secp256k1 key and the S
+// value of sig is over half the curve order. Such signatures are malleable and
+// are rejected by the chains, convertDERtoBER normalizes them for ledger
+// signatures.
+func checkSecp256k1LowS(pubKey cryptotypes.PubKey, sig []byte) error {
+	if _, ok := pubKey.(*secp256k1.PubKey); !ok || len(sig) != 64 {
+		return nil
+	}
+	var s btcec.ModNScalar
+	s.SetByteSlice(sig[32:])
+	if s.IsOverHalfOrder() {
+		return fmt.Errorf("%w: signature is not in lower-S form", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// decodeAminoMultisignature decodes the amino encoded multisignature bz into
+// a MultiSignatureData, using the members of pubKey to decode nested
+// multisignatures.
+func decodeAminoMultisignature(cdc *codec.Codec, pubKey multisig.PubKey, bz []byte) (*signing.MultiSignatureData, error) {
+	var aminoSig multisig.AminoMultisignature
+	if err := cdc.Amino.Unmarshal(bz, &aminoSig); err != nil {
+		return nil, fmt.Errorf("%w: cannot decode multisignature: %v", ErrInvalidSignature, err)
+	}
+	if aminoSig.BitArray == nil {
+		return nil, fmt.Errorf("%w: multisignature has no bit array", ErrInvalidSignature)
+	}
+	var (
+		pubKeys = pubKey.GetPubKeys()
+		sig     = &signing.MultiSignatureData{BitArray: aminoSig.BitArray}
+	)
+	for i := 0; i < aminoSig.BitArray.Count() && i < len(pubKeys); i++ {
+		if !aminoSig.BitArray.GetIndex(i) {
+			continue
+		}
+		if len(sig.Signatures) >= len(aminoSig.Sigs) {
+			return nil, fmt.Errorf("%w: multisignature has not enough signatures", ErrInvalidSignature)
+		}
+		memberSig := aminoSig.Sigs[len(sig.Signatures)]
+		nested, ok := pubKeys[i].(multisig.PubKey)
+		if !ok {
+			sig.Signatures = append(sig.Signatures, &signing.SingleSignatureData{
+				SignMode:  signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON,
+				Signature: memberSig,
+			})
+			continue
+		}
+		nestedSig, err := decodeAminoMultisignature(cdc, nested, memberSig)
+		if err != nil {
+			return nil, err
+		}
+		sig.Signatures = append(sig.Signatures, nestedSig)
+	}
+	return sig, nil
+}
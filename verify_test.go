@@ -0,0 +1,146 @@
+package keyring_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbruyelle/keyring-compat"
+	"github.com/tbruyelle/keyring-compat/codec"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	cosmoskeyring "github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	kmultisig "github.com/cosmos/cosmos-sdk/crypto/keys/multisig"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/cosmos/cosmos-sdk/crypto/types/multisig"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+)
+
+// highS returns sig with its S value replaced by N - S.
+func highS(sig []byte) []byte {
+	s := new(big.Int).SetBytes(sig[32:])
+	s.Sub(btcec.S256().N, s)
+	bz := make([]byte, 64)
+	copy(bz, sig[:32])
+	s.FillBytes(bz[32:])
+	return bz
+}
+
+func TestVerify(t *testing.T) {
+	msg := []byte("message")
+	for _, encoding := range []keyring.Encoding{keyring.ProtoEncoding, keyring.AminoEncoding} {
+		t.Run(string(encoding), func(t *testing.T) {
+			var (
+				kr, _ = newLedgerKeyring(t)
+				secp  = secp256k1.GenPrivKeyFromSecret([]byte("secp"))
+				ed    = ed25519.GenPrivKeyFromSecret([]byte("ed"))
+				// offlinePrivKey is the private key of the offline key, which is not
+				// stored in the keyring.
+				offlinePrivKey = secp256k1.GenPrivKeyFromSecret([]byte("offline"))
+				members        = []cryptotypes.PrivKey{
+					secp256k1.GenPrivKeyFromSecret([]byte("m1")),
+					secp256k1.GenPrivKeyFromSecret([]byte("m2")),
+					secp256k1.GenPrivKeyFromSecret([]byte("m3")),
+				}
+				memberPubKeys = []cryptotypes.PubKey{members[0].PubKey(), members[1].PubKey(), members[2].PubKey()}
+				multiPubKey   = kmultisig.NewLegacyAminoPubKey(2, memberPubKeys)
+			)
+			sign := func(privKey cryptotypes.PrivKey) []byte {
+				t.Helper()
+				sig, err := privKey.Sign(msg)
+				require.NoError(t, err)
+				return sig
+			}
+			localRecord, err := cosmoskeyring.NewLocalRecord("local", secp, secp.PubKey())
+			require.NoError(t, err)
+			local := addRecord(t, kr, localRecord, encoding)
+			edRecord, err := cosmoskeyring.NewLocalRecord("ed", ed, ed.PubKey())
+			require.NoError(t, err)
+			edKey := addRecord(t, kr, edRecord, encoding)
+			offlineRecord, err := cosmoskeyring.NewOfflineRecord("offline", offlinePrivKey.PubKey())
+			require.NoError(t, err)
+			offline := addRecord(t, kr, offlineRecord, encoding)
+			ledger, err := kr.AddLedger("ledger", hd.NewFundraiserParams(0, 118, 0), encoding)
+			require.NoError(t, err)
+			multiRecord, err := cosmoskeyring.NewMultiRecord("multi", multiPubKey)
+			require.NoError(t, err)
+			multi := addRecord(t, kr, multiRecord, encoding)
+
+			localSig, err := local.Sign(msg)
+			require.NoError(t, err)
+			ledgerSig, err := ledger.Sign(msg)
+			require.NoError(t, err)
+			multiSig := multisig.NewMultisig(3)
+			require.NoError(t, multisig.AddSignatureFromPubKey(multiSig,
+				&signing.SingleSignatureData{SignMode: signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON, Signature: sign(members[0])},
+				memberPubKeys[0], memberPubKeys))
+			require.NoError(t, multisig.AddSignatureFromPubKey(multiSig,
+				&signing.SingleSignatureData{SignMode: signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON, Signature: sign(members[2])},
+				memberPubKeys[2], memberPubKeys))
+			aminoMultiSig, err := codec.Amino.Marshal(multisig.AminoMultisignature{
+				BitArray: multiSig.BitArray,
+				Sigs:     [][]byte{sign(members[0]), sign(members[2])},
+			})
+			require.NoError(t, err)
+			notEnoughSig := multisig.NewMultisig(3)
+			require.NoError(t, multisig.AddSignatureFromPubKey(notEnoughSig,
+				&signing.SingleSignatureData{SignMode: signing.SignMode_SIGN_MODE_LEGACY_AMINO_JSON, Signature: sign(members[1])},
+				memberPubKeys[1], memberPubKeys))
+
+			tests := []struct {
+				name          string
+				key           keyring.Key
+				sig           []byte
+				expectedError string
+			}{
+				{name: "local", key: local, sig: localSig},
+				{name: "local ed25519", key: edKey, sig: sign(ed)},
+				{name: "ledger", key: ledger, sig: ledgerSig},
+				{name: "offline", key: offline, sig: sign(offlinePrivKey)},
+				{name: "multisig", key: multi, sig: aminoMultiSig},
+				{
+					name:          "wrong key",
+					key:           ledger,
+					sig:           localSig,
+					expectedError: "invalid signature",
+				},
+				{
+					name:          "high S",
+					key:           local,
+					sig:           highS(localSig),
+					expectedError: "invalid signature: signature is not in lower-S form",
+				},
+				{
+					name:          "multisig garbage",
+					key:           multi,
+					sig:           localSig,
+					expectedError: "invalid signature: cannot decode multisignature",
+				},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					err := tt.key.Verify(msg, tt.sig)
+
+					if tt.expectedError != "" {
+						require.ErrorIs(t, err, keyring.ErrInvalidSignature)
+						assert.ErrorContains(t, err, tt.expectedError)
+						return
+					}
+					require.NoError(t, err)
+				})
+			}
+
+			t.Run("VerifyMultisig", func(t *testing.T) {
+				assert := assert.New(t)
+				assert.NoError(multi.VerifyMultisig(msg, multiSig))
+				assert.ErrorContains(multi.VerifyMultisig(msg, notEnoughSig), "invalid signature: signature size is incorrect 1")
+				assert.ErrorContains(multi.VerifyMultisig([]byte("other"), multiSig), "unable to verify signature at index 0")
+				assert.EqualError(local.VerifyMultisig(msg, multiSig), "key local.info is not a multisig key")
+			})
+		})
+	}
+}